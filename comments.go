@@ -0,0 +1,136 @@
+package iniparser
+
+import (
+	"io"
+	"strings"
+)
+
+// GetComment returns the comment lines directly above key in section,
+// joined with newlines and without their comment prefix, or "" if the key
+// has none. With key "" it returns the comment above the section header.
+// Comments are recorded when loading with comment preservation enabled,
+// and by SetComment.
+func (p *Parser) GetComment(section, key string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys, ok := p.section(section)
+	if !ok {
+		return "", ErrSectionNotFound
+	}
+	if key != "" {
+		if key, ok = lookupName(keys, key, p.caseInsensitive); !ok {
+			return "", ErrKeyNotFound
+		}
+	}
+	section, _ = lookupName(p.parsedData, section, p.caseInsensitive)
+	lines := p.comments[section][key]
+	text := make([]string, len(lines))
+	for i, line := range lines {
+		text[i] = p.commentText(line)
+	}
+	return strings.Join(text, "\n"), nil
+}
+
+// SetComment replaces the comment written above key in section, or above
+// the section header when key is "". Each line of comment is written with
+// the first configured comment prefix; an empty comment removes it. It
+// returns ErrSectionNotFound or ErrKeyNotFound if there is nothing to
+// attach the comment to, and ErrNoCommentPrefix if comments were disabled
+// with SetCommentPrefixes, since the comment could not be read back.
+func (p *Parser) SetComment(section, key, comment string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prefixes := p.prefixes()
+	if comment != "" && len(prefixes) == 0 {
+		return ErrNoCommentPrefix
+	}
+
+	keys, ok := p.section(section)
+	if !ok {
+		return ErrSectionNotFound
+	}
+	if key != "" {
+		if key, ok = lookupName(keys, key, p.caseInsensitive); !ok {
+			return ErrKeyNotFound
+		}
+	}
+	section, _ = lookupName(p.parsedData, section, p.caseInsensitive)
+	p.markDirty(section)
+	if comment == "" {
+		p.dropComments(section, key)
+		return nil
+	}
+
+	var lines []string
+	for _, text := range strings.Split(comment, "\n") {
+		lines = append(lines, strings.TrimRight(prefixes[0]+" "+text, " "))
+	}
+	if p.comments == nil {
+		p.comments = make(map[string]map[string][]string)
+	}
+	if p.comments[section] == nil {
+		p.comments[section] = make(map[string][]string)
+	}
+	p.comments[section][key] = lines
+	return nil
+}
+
+// commentText strips the comment prefix, and one space after it, from line.
+func (s *settings) commentText(line string) string {
+	for _, prefix := range s.prefixes() {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line[len(prefix):], " ")
+		}
+	}
+	return line
+}
+
+// keepComment holds a comment line until the header or key it documents is
+// read. It does nothing unless comments are preserved.
+func (lp *lineParser) keepComment(line string) {
+	if lp.preserveComments {
+		lp.pending = append(lp.pending, line)
+	}
+}
+
+// attachComments files the pending comment lines under key in section,
+// where key "" stands for the section header.
+func (lp *lineParser) attachComments(section, key string) {
+	if len(lp.pending) == 0 {
+		return
+	}
+	if lp.comments == nil {
+		lp.comments = make(map[string]map[string][]string)
+	}
+	if lp.comments[section] == nil {
+		lp.comments[section] = make(map[string][]string)
+	}
+	lp.comments[section][key] = append(lp.comments[section][key], lp.pending...)
+	lp.pending = nil
+}
+
+// writeComments writes each comment line to w.
+func writeComments(w io.Writer, lines []string) {
+	for _, line := range lines {
+		io.WriteString(w, line+"\n")
+	}
+}
+
+// moveComments re-files the comments of key from in section under key to.
+// It must be called with p.mu held.
+func (p *Parser) moveComments(section, from, to string) {
+	section, _ = lookupName(p.comments, section, p.caseInsensitive)
+	if lines, ok := p.comments[section][from]; ok {
+		delete(p.comments[section], from)
+		p.comments[section][to] = lines
+	}
+}
+
+// dropComments forgets the comments of key in section. It must be called
+// with p.mu held.
+func (p *Parser) dropComments(section, key string) {
+	section, _ = lookupName(p.comments, section, p.caseInsensitive)
+	delete(p.comments[section], key)
+}
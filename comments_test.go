@@ -0,0 +1,115 @@
+package iniparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		key      string
+		comment  string
+		wantText string
+		wantOut  string
+	}{
+		{"key", nil, "k", "explains k", "explains k", "[s]\n; explains k\nk=v\n"},
+		{"section header", nil, "", "about s", "about s", "; about s\n[s]\nk=v\n"},
+		{"multiple lines", nil, "k", "one\n\ntwo", "one\n\ntwo", "[s]\n; one\n;\n; two\nk=v\n"},
+		{"first configured prefix", []Option{WithCommentPrefixes("#")}, "k", "hash", "hash", "[s]\n# hash\nk=v\n"},
+		{"empty removes", []Option{WithPreserveComments()}, "k", "", "", "[s]\nk=v\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\n; old\nk=v", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.SetComment("s", tt.key, tt.comment); err != nil {
+				t.Fatalf("SetComment() error = %v", err)
+			}
+			if got, _ := p.GetComment("s", tt.key); got != tt.wantText {
+				t.Errorf("GetComment() = %q, want %q", got, tt.wantText)
+			}
+			if got := p.String(); got != tt.wantOut {
+				t.Errorf("String() = %q, want %q", got, tt.wantOut)
+			}
+			reloaded, err := Parse(p.String(), append(tt.opts, WithPreserveComments(), WithStrict())...)
+			if err != nil {
+				t.Fatalf("reload error = %v", err)
+			}
+			if got, _ := reloaded.GetComment("s", tt.key); got != tt.wantText {
+				t.Errorf("GetComment() after reload = %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestSetCommentErrors(t *testing.T) {
+	p, err := Parse("[s]\nk=v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetComment("missing", "", "c"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("SetComment() error = %v, want %v", err, ErrSectionNotFound)
+	}
+	if err := p.SetComment("s", "missing", "c"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("SetComment() error = %v, want %v", err, ErrKeyNotFound)
+	}
+	p.SetCommentPrefixes()
+	if err := p.SetComment("s", "k", "c"); !errors.Is(err, ErrNoCommentPrefix) {
+		t.Errorf("SetComment() with comments disabled error = %v, want %v", err, ErrNoCommentPrefix)
+	}
+	if got := p.String(); got != "[s]\nk=v\n" {
+		t.Errorf("String() = %q, want the comment left out", got)
+	}
+}
+
+func TestGetComment(t *testing.T) {
+	const input = `; owner details
+[owner]
+; full name
+;   of the owner
+name = John
+# organization
+org = Acme
+plain = x
+`
+	tests := []struct {
+		name    string
+		section string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"single line", "owner", "org", "organization", nil},
+		{"multiple lines keep inner spacing", "owner", "name", "full name\n  of the owner", nil},
+		{"no comment", "owner", "plain", "", nil},
+		{"section header", "owner", "", "owner details", nil},
+		{"missing key", "owner", "missing", "", ErrKeyNotFound},
+		{"missing section", "db", "name", "", ErrSectionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(input, WithPreserveComments())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.GetComment(tt.section, tt.key)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("GetComment() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetCommentWithoutPreservation(t *testing.T) {
+	p, err := Parse("[s]\n; about k\nk=v\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := p.GetComment("s", "k"); err != nil || got != "" {
+		t.Errorf("GetComment() = %q, %v, want no comment", got, err)
+	}
+}
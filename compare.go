@@ -0,0 +1,42 @@
+package iniparser
+
+// Equal reports whether p and other hold the same sections, keys and values.
+// When both parsers are case-insensitive, section and key names are compared
+// ignoring case, so [Owner] and [owner] are equal; otherwise names must match
+// exactly, so the result does not depend on which parser Equal is called on.
+// Values are always compared exactly.
+func (p *Parser) Equal(other *Parser) bool {
+	if p == other {
+		return true
+	}
+	if other == nil {
+		return false
+	}
+	other.mu.RLock()
+	theirFold := other.caseInsensitive
+	other.mu.RUnlock()
+	theirs := other.GetSections()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	fold := p.caseInsensitive && theirFold
+
+	if len(p.parsedData) != len(theirs) {
+		return false
+	}
+	for name, keys := range p.parsedData {
+		otherName, ok := lookupName(theirs, name, fold)
+		otherKeys := theirs[otherName]
+		if !ok || len(keys) != len(otherKeys) {
+			return false
+		}
+		for key, value := range keys {
+			otherKey, ok := lookupName(otherKeys, key, fold)
+			if !ok || otherKeys[otherKey] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package iniparser
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      string
+		aFold     bool
+		bFold     bool
+		wantEqual bool
+	}{
+		{"identical", "[s]\nk=v", "[s]\nk=v", false, false, true},
+		{"different value", "[s]\nk=v", "[s]\nk=w", false, false, false},
+		{"value case differs", "[s]\nk=v", "[s]\nk=V", true, true, false},
+		{"different order", "[s]\na=1\nb=2\n[t]\nc=3", "[t]\nc=3\n[s]\nb=2\na=1", false, false, true},
+		{"comments ignored", "; note\n[s]\nk=v", "[s]\n; other\nk=v", false, false, true},
+		{"extra key", "[s]\nk=v", "[s]\nk=v\nx=1", false, false, false},
+		{"missing key", "[s]\nk=v\nx=1", "[s]\nk=v\ny=1", false, false, false},
+		{"key in another section", "[s]\nk=v\n[t]", "[s]\n[t]\nk=v", false, false, false},
+		{"extra section", "[s]\nk=v", "[s]\nk=v\n[t]\nx=1", false, false, false},
+		{"name case, both case-sensitive", "[Owner]\nName=v", "[owner]\nname=v", false, false, false},
+		{"name case, both case-insensitive", "[Owner]\nName=v", "[owner]\nname=v", true, true, true},
+		{"name case, only first case-insensitive", "[Owner]\nName=v", "[owner]\nname=v", true, false, false},
+		{"name case, only second case-insensitive", "[Owner]\nName=v", "[owner]\nname=v", false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Parse(tt.a, caseOption(tt.aFold)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := Parse(tt.b, caseOption(tt.bFold)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.Equal(b); got != tt.wantEqual {
+				t.Errorf("a.Equal(b) = %v, want %v", got, tt.wantEqual)
+			}
+			if got := b.Equal(a); got != tt.wantEqual {
+				t.Errorf("b.Equal(a) = %v, want %v", got, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestEqualNilAndSelf(t *testing.T) {
+	p, err := Parse("[s]\nk=v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(p) {
+		t.Error("p.Equal(p) = false")
+	}
+	if p.Equal(nil) {
+		t.Error("p.Equal(nil) = true")
+	}
+}
+
+func caseOption(fold bool) []Option {
+	if fold {
+		return []Option{WithCaseInsensitive()}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package iniparser
+
+// IsDirty reports whether the content changed since it was last loaded or
+// saved with SaveToFile or SaveToFileMode.
+func (p *Parser) IsDirty() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.dirty) > 0
+}
+
+// DirtySections returns, in lexicographic order, the names of the sections
+// changed since the content was last loaded or saved to a file, including
+// sections that were deleted.
+func (p *Parser) DirtySections() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return sortedKeys(p.dirty)
+}
+
+// markDirty records that section changed. It must be called with p.mu held.
+func (p *Parser) markDirty(section string) {
+	section, _ = lookupName(p.parsedData, section, p.caseInsensitive)
+	if p.dirty == nil {
+		p.dirty = make(map[string]bool)
+	}
+	p.dirty[section] = true
+}
@@ -0,0 +1,52 @@
+package iniparser
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIsDirty(t *testing.T) {
+	tests := []struct {
+		name string
+		save func(p *Parser, path string) error
+	}{
+		{"SaveToFile", (*Parser).SaveToFile},
+		{"SaveToFileMode", func(p *Parser, path string) error { return p.SaveToFileMode(path, 0600) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFiles(t, "[a]\nk=1\n\n[b]\nk=2\n\n[c]\nk=3\n")[0]
+			p := NewParser()
+			if err := p.LoadFromFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if p.IsDirty() || len(p.DirtySections()) != 0 {
+				t.Fatalf("after loading IsDirty() = %v, DirtySections() = %q, want clean", p.IsDirty(), p.DirtySections())
+			}
+
+			if err := p.Set("c", "k", "30"); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Set("new", "k", "4"); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.DeleteSection("a"); err != nil {
+				t.Fatal(err)
+			}
+			if !p.IsDirty() {
+				t.Error("IsDirty() = false after Set, want true")
+			}
+			if got, want := p.DirtySections(), []string{"a", "c", "new"}; !slices.Equal(got, want) {
+				t.Errorf("DirtySections() = %q, want %q", got, want)
+			}
+
+			if err := tt.save(p, filepath.Join(t.TempDir(), "out.ini")); err != nil {
+				t.Fatal(err)
+			}
+			if p.IsDirty() || len(p.DirtySections()) != 0 {
+				t.Errorf("after saving IsDirty() = %v, DirtySections() = %q, want clean", p.IsDirty(), p.DirtySections())
+			}
+		})
+	}
+}
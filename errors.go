@@ -0,0 +1,129 @@
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmptyString is returned in strict mode when the input to be parsed
+	// is empty or blank.
+	ErrEmptyString = errors.New("input string is empty")
+	// ErrSectionIsEmpty is returned when a section name is empty.
+	ErrSectionIsEmpty = errors.New("section name is empty")
+	// ErrKeyIsEmpty is returned when a key name is empty.
+	ErrKeyIsEmpty = errors.New("key is empty")
+	// ErrValueIsEmpty is returned when a key has no value.
+	ErrValueIsEmpty = errors.New("value is empty")
+	// ErrSeparatorIsEmpty is returned by GetStringSlice for an empty
+	// separator.
+	ErrSeparatorIsEmpty = errors.New("separator is empty")
+	// ErrSectionNotFound is returned when the requested section does not exist.
+	ErrSectionNotFound = errors.New("section not found")
+	// ErrLineBreak is returned when a section name, key or value passed to
+	// a setter contains a line break, which could not be written back on a
+	// single line.
+	ErrLineBreak = errors.New("contains a line break")
+	// ErrKeyNotFound is returned when the requested key does not exist in the section.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyAlreadyExists is returned when adding a key that is already present.
+	ErrKeyAlreadyExists = errors.New("key already exists")
+	// ErrDuplicateKey is returned in case-insensitive mode when a section
+	// holds two spellings of the same key, such as "Name" and "name", and
+	// with DuplicateError when a section repeats a key.
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrMalformedSectionHeader is returned when a section header is missing
+	// its opening or closing bracket.
+	ErrMalformedSectionHeader = errors.New("malformed section header")
+	// ErrKeyOutsideSection is returned in strict mode when a key appears
+	// before any section header.
+	ErrKeyOutsideSection = errors.New("key outside of any section")
+	// ErrMalformedLine is returned in strict mode for a line that is neither
+	// a comment, a section header nor a key/value pair.
+	ErrMalformedLine = errors.New("malformed line")
+	// ErrUnexpectedIndentation is returned when indentation is disallowed
+	// and a section header or key line is indented.
+	ErrUnexpectedIndentation = errors.New("unexpected indentation")
+	// ErrLineTooLong is returned when a line read from a stream exceeds the
+	// configured maximum line length.
+	ErrLineTooLong = errors.New("line too long")
+	// ErrIncludeCycle is returned when an include directive names a file
+	// that is already being included.
+	ErrIncludeCycle = errors.New("include cycle")
+	// ErrMissingRequired is returned by Validate when required sections or
+	// keys are missing.
+	ErrMissingRequired = errors.New("missing required entries")
+	// ErrInvalidUTF8 is returned when UTF-8 validation is enabled and the
+	// input is not valid UTF-8.
+	ErrInvalidUTF8 = errors.New("invalid UTF-8")
+	// ErrNoCommentPrefix is returned by SetComment when comments are
+	// disabled because no comment prefix is configured.
+	ErrNoCommentPrefix = errors.New("no comment prefix configured")
+	// ErrNoFileLoaded is returned by Reload when the content was not loaded
+	// from a file.
+	ErrNoFileLoaded = errors.New("no file loaded")
+	// ErrInvalidLineEnding is returned by SetLineEnding for anything but
+	// "\n" and "\r\n".
+	ErrInvalidLineEnding = errors.New("invalid line ending")
+	// ErrValueNotInteger is returned when a value cannot be parsed as an integer.
+	ErrValueNotInteger = errors.New("value is not an integer")
+	// ErrValueNotBool is returned when a value cannot be parsed as a boolean.
+	ErrValueNotBool = errors.New("value is not a boolean")
+	// ErrValueNotFloat is returned when a value cannot be parsed as a float.
+	ErrValueNotFloat = errors.New("value is not a float")
+	// ErrNotADuration is returned when a value cannot be parsed as a duration.
+	ErrNotADuration = errors.New("value is not a duration")
+	// ErrNotAByteSize is returned when a value cannot be parsed as a byte
+	// size.
+	ErrNotAByteSize = errors.New("value is not a byte size")
+	// ErrNotAnIP is returned when a value cannot be parsed as an IP address.
+	ErrNotAnIP = errors.New("value is not an IP address")
+	// ErrNotAURL is returned when a value cannot be parsed as a URL.
+	ErrNotAURL = errors.New("value is not a URL")
+	// ErrInvalidEnumValue is returned when a value is not one of the
+	// permitted options.
+	ErrInvalidEnumValue = errors.New("value is not a permitted option")
+)
+
+// ParseError describes a problem found while parsing INI input. Err holds
+// the sentinel describing the kind of failure, so errors.Is keeps working
+// through Unwrap. Section is the section in effect when the line was read,
+// "" before the first header, and Raw is the line as it appeared in the
+// input; both are empty for failures not tied to a single line.
+type ParseError struct {
+	Line    int
+	Column  int
+	Section string
+	Raw     string
+	Msg     string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError builds a ParseError whose message is err's text followed by
+// an optional detail.
+func newParseError(line, column int, err error, detail string) *ParseError {
+	msg := err.Error()
+	if detail != "" {
+		msg += ": " + detail
+	}
+	return &ParseError{Line: line, Column: column, Msg: msg, Err: err}
+}
+
+// Warning describes a non-fatal problem found while parsing, such as a
+// skipped line or a duplicate key.
+type Warning struct {
+	Line    int
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+}
@@ -0,0 +1,56 @@
+package iniparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantErr     error
+		wantLine    int
+		wantColumn  int
+		wantSection string
+		wantRaw     string
+	}{
+		{"empty value", "[owner]\nname=John\norg=\n", ErrValueIsEmpty, 3, 5, "owner", "org="},
+		{"empty key", "[db]\n  = 5432\n", ErrKeyIsEmpty, 2, 3, "db", "  = 5432"},
+		{"before any section", "=x\n", ErrKeyIsEmpty, 1, 1, "", "=x"},
+		{"bad header", "[a]\nk=v\n[b\n", ErrMalformedSectionHeader, 3, 1, "a", "[b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewParser().LoadFromString(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromString() error = %v, want %v", err, tt.wantErr)
+			}
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("error is %T, want *ParseError", err)
+			}
+			if pe.Line != tt.wantLine || pe.Column != tt.wantColumn {
+				t.Errorf("position = %d:%d, want %d:%d", pe.Line, pe.Column, tt.wantLine, tt.wantColumn)
+			}
+			if pe.Section != tt.wantSection || pe.Raw != tt.wantRaw {
+				t.Errorf("Section, Raw = %q, %q, want %q, %q", pe.Section, pe.Raw, tt.wantSection, tt.wantRaw)
+			}
+			if pe.Unwrap() != tt.wantErr {
+				t.Errorf("Unwrap() = %v, want %v", pe.Unwrap(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseErrorFromFile(t *testing.T) {
+	path := writeFiles(t, "[s]\nk=\n")[0]
+	err := NewParser().LoadFromFile(path)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 2 || pe.Raw != "k=" {
+		t.Fatalf("LoadFromFile() error = %v, want a *ParseError for line 2", err)
+	}
+	if !errors.Is(err, ErrValueIsEmpty) {
+		t.Errorf("errors.Is(%v, ErrValueIsEmpty) = false", err)
+	}
+}
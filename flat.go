@@ -0,0 +1,75 @@
+package iniparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultFlatSeparator joins section and key names in FlatMap when no
+// separator is given.
+const defaultFlatSeparator = "."
+
+// FlatMap returns every value keyed by its section and key name joined with
+// sep, such as "owner.name". Keys of the unnamed section are used as they
+// are. An empty sep defaults to ".".
+func (p *Parser) FlatMap(sep string) map[string]string {
+	if sep == "" {
+		sep = defaultFlatSeparator
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	flat := make(map[string]string)
+	for name, keys := range p.parsedData {
+		for key, value := range keys {
+			if name != "" {
+				key = name + sep + key
+			}
+			flat[key] = value
+		}
+	}
+	return flat
+}
+
+// LoadFromFlatMap replaces the parser's content with m, the inverse of
+// FlatMap. Each key is split on its first sep into a section and a key
+// name, so a section name containing sep does not survive a round trip; a
+// key without sep goes to the unnamed section. An empty sep defaults to
+// ".". On error the current content is kept.
+func (p *Parser) LoadFromFlatMap(m map[string]string, sep string) error {
+	if sep == "" {
+		sep = defaultFlatSeparator
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	data := make(map[string]map[string]string)
+	for flatKey, value := range m {
+		section, key, found := strings.Cut(flatKey, sep)
+		if !found {
+			section, key = "", flatKey
+		}
+		switch {
+		case found && section == "":
+			return fmt.Errorf("%w: %q", ErrSectionIsEmpty, flatKey)
+		case key == "":
+			return fmt.Errorf("%w: %q", ErrKeyIsEmpty, flatKey)
+		case value == "" && !p.allowEmptyValues:
+			return fmt.Errorf("%w: %q", ErrValueIsEmpty, flatKey)
+		}
+		if err := checkLineBreaks(flatKey, value); err != nil {
+			return err
+		}
+		name, ok := lookupName(data, section, p.caseInsensitive)
+		if !ok {
+			data[name] = make(map[string]string)
+		}
+		keys := data[name]
+		key, _ = lookupName(keys, key, p.caseInsensitive)
+		keys[key] = value
+	}
+	p.document = document{parsedData: data}
+	return nil
+}
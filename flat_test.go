@@ -0,0 +1,65 @@
+package iniparser
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestFlatMapRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		sep   string
+	}{
+		{"dotted", "global=yes\n[owner]\nname=John\n[database]\nport=143\nfile=payroll.dat\n", "."},
+		{"default separator", "[owner]\nname=John\n", ""},
+		{"custom separator", "[a.b]\nc.d=1\n", "::"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			flat := p.FlatMap(tt.sep)
+			restored := NewParser()
+			if err := restored.LoadFromFlatMap(flat, tt.sep); err != nil {
+				t.Fatalf("LoadFromFlatMap() error = %v", err)
+			}
+			if !restored.Equal(p) {
+				t.Errorf("restored %v, want %v", restored.GetSections(), p.GetSections())
+			}
+			if got := restored.FlatMap(tt.sep); !maps.Equal(got, flat) {
+				t.Errorf("FlatMap() after restore = %v, want %v", got, flat)
+			}
+		})
+	}
+}
+
+func TestLoadFromFlatMapErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       map[string]string
+		wantErr error
+	}{
+		{"empty section", map[string]string{".k": "v"}, ErrSectionIsEmpty},
+		{"empty key", map[string]string{"s.": "v"}, ErrKeyIsEmpty},
+		{"empty value", map[string]string{"s.k": ""}, ErrValueIsEmpty},
+		{"line break", map[string]string{"s.k": "a\nb"}, ErrLineBreak},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[keep]\nk=v\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.LoadFromFlatMap(tt.m, "."); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromFlatMap() error = %v, want %v", err, tt.wantErr)
+			}
+			if got, _ := p.Get("keep", "k"); got != "v" {
+				t.Errorf("content was replaced despite the error")
+			}
+		})
+	}
+}
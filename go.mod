@@ -0,0 +1,3 @@
+module github.com/codescalersinternships/INIParser-EYAD_HUSSEIN
+
+go 1.22
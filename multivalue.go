@@ -0,0 +1,55 @@
+package iniparser
+
+// GetValues returns every value of key in section, in the order they were
+// loaded. Keys that were not repeated, or not loaded in multi-value mode,
+// yield a single value.
+func (p *Parser) GetValues(section, key string) ([]string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys, ok := p.section(section)
+	if !ok {
+		return nil, ErrSectionNotFound
+	}
+	key, ok = lookupName(keys, key, p.caseInsensitive)
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	section, _ = lookupName(p.parsedData, section, p.caseInsensitive)
+	if values, ok := p.values[section][key]; ok {
+		return append([]string(nil), values...), nil
+	}
+	return []string{keys[key]}, nil
+}
+
+// appendValue records value as a further value of key in section, whose
+// value so far was prev.
+func (lp *lineParser) appendValue(section, key, prev, value string) {
+	if lp.values == nil {
+		lp.values = make(map[string]map[string][]string)
+	}
+	if lp.values[section] == nil {
+		lp.values[section] = make(map[string][]string)
+	}
+	if _, ok := lp.values[section][key]; !ok {
+		lp.values[section][key] = []string{prev}
+	}
+	lp.values[section][key] = append(lp.values[section][key], value)
+}
+
+// moveValues re-files the values of key from in section under key to. It
+// must be called with p.mu held.
+func (p *Parser) moveValues(section, from, to string) {
+	section, _ = lookupName(p.values, section, p.caseInsensitive)
+	if values, ok := p.values[section][from]; ok {
+		delete(p.values[section], from)
+		p.values[section][to] = values
+	}
+}
+
+// dropValues forgets the repeated values of key in section, leaving the
+// single value in parsedData. It must be called with p.mu held.
+func (p *Parser) dropValues(section, key string) {
+	section, _ = lookupName(p.values, section, p.caseInsensitive)
+	delete(p.values[section], key)
+}
@@ -0,0 +1,57 @@
+package iniparser
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGetValues(t *testing.T) {
+	const input = "[pool]\nserver=a\nserver=b\nserver=c\nsize=3\n"
+	tests := []struct {
+		name    string
+		opts    []Option
+		key     string
+		want    []string
+		wantErr error
+	}{
+		{"three servers", []Option{WithMultiValue()}, "server", []string{"a", "b", "c"}, nil},
+		{"single value", []Option{WithMultiValue()}, "size", []string{"3"}, nil},
+		{"case-insensitive", []Option{WithMultiValue(), WithCaseInsensitive()}, "SERVER", []string{"a", "b", "c"}, nil},
+		{"off keeps the last", nil, "server", []string{"c"}, nil},
+		{"missing key", []Option{WithMultiValue()}, "missing", nil, ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(input, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.GetValues("pool", tt.key)
+			if !errors.Is(err, tt.wantErr) || !slices.Equal(got, tt.want) {
+				t.Errorf("GetValues() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMultiValueRoundTrip(t *testing.T) {
+	const input = "[pool]\nserver=a\nserver=b\nserver=c\n"
+	p, err := Parse(input, WithMultiValue())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.String(); got != input {
+		t.Errorf("String() = %q, want %q", got, input)
+	}
+	if got, _ := p.Get("pool", "server"); got != "c" {
+		t.Errorf("Get() = %q, want the last value", got)
+	}
+
+	if err := p.Set("pool", "server", "z"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := p.GetValues("pool", "server"); !slices.Equal(got, []string{"z"}) {
+		t.Errorf("GetValues() after Set = %q, want [z]", got)
+	}
+}
@@ -0,0 +1,428 @@
+package iniparser
+
+import "fmt"
+
+// Option configures a Parser created by NewParser.
+type Option func(*Parser)
+
+// settings holds a parser's configuration. It is embedded in Parser so the
+// configuration can be copied to another parser in one assignment.
+type settings struct {
+	allowEmptyValues  bool
+	strict            bool
+	commentPrefixes   []string
+	inlineComments    bool
+	caseInsensitive   bool
+	separators        string
+	maxLineLength     int
+	collectErrors     bool
+	outputDelimiter   string
+	includeKey        string
+	preserveComments  bool
+	validateUTF8      bool
+	multiValue        bool
+	keyNormalizer     func(section, key string) string
+	rejectIndentation bool
+	lineEnding        string
+	duplicates        DuplicateStrategy
+	defaultSection    string
+}
+
+// DuplicateStrategy selects what loading does with a key repeated within a
+// section when multi-value mode is off.
+type DuplicateStrategy int
+
+const (
+	// DuplicateLast keeps the last value of a repeated key. It is the
+	// default.
+	DuplicateLast DuplicateStrategy = iota
+	// DuplicateFirst keeps the first value and ignores later ones.
+	DuplicateFirst
+	// DuplicateError fails loading with ErrDuplicateKey.
+	DuplicateError
+)
+
+// defaultCommentPrefixes are used when no comment prefixes were configured.
+var defaultCommentPrefixes = []string{";", "#"}
+
+// defaultSeparators are used when no key/value separator was configured.
+const defaultSeparators = "="
+
+// defaultIncludeKey is the include directive enabled by WithIncludes.
+const defaultIncludeKey = "include"
+
+// defaultMaxLineLength is the longest line LoadFromReader accepts when no
+// limit was configured.
+const defaultMaxLineLength = 1 << 20
+
+// WithCaseInsensitive makes section and key lookups ignore case. Names keep
+// the spelling they were first loaded or set with. Loading fails with
+// ErrDuplicateKey if a section spells the same key in two ways.
+func WithCaseInsensitive() Option {
+	return func(p *Parser) {
+		p.caseInsensitive = true
+	}
+}
+
+// WithAllowEmptyValues is the option form of AllowEmptyValues(true).
+func WithAllowEmptyValues() Option {
+	return func(p *Parser) {
+		p.allowEmptyValues = true
+	}
+}
+
+// WithCommentPrefixes is the option form of SetCommentPrefixes.
+func WithCommentPrefixes(prefixes ...string) Option {
+	return func(p *Parser) {
+		p.commentPrefixes = commentPrefixes(prefixes)
+	}
+}
+
+// WithSeparator sets the character separating keys from values, "=" by
+// default. It is used both when parsing and when writing.
+func WithSeparator(sep byte) Option {
+	return func(p *Parser) {
+		p.separators = string(sep)
+	}
+}
+
+// WithDelimiters accepts any of delims as the character separating keys
+// from values, splitting each line on the first one found. The first
+// delimiter is used when writing. Without delimiters the default "=" is
+// used.
+func WithDelimiters(delims ...byte) Option {
+	return func(p *Parser) {
+		p.separators = string(delims)
+	}
+}
+
+// WithStrict is the option form of SetStrict(true).
+func WithStrict() Option {
+	return func(p *Parser) {
+		p.strict = true
+	}
+}
+
+// WithPropertiesDialect configures the parser for Java .properties style
+// input: "#" and "!" start comments, and keys are separated from values by
+// "=" or ":". Output is written with "=".
+func WithPropertiesDialect() Option {
+	return func(p *Parser) {
+		p.commentPrefixes = []string{"#", "!"}
+		p.separators = "=:"
+	}
+}
+
+// WithPreserveComments is the option form of SetPreserveComments(true).
+func WithPreserveComments() Option {
+	return func(p *Parser) {
+		p.preserveComments = true
+	}
+}
+
+// WithValidateUTF8 is the option form of SetValidateUTF8(true).
+func WithValidateUTF8() Option {
+	return func(p *Parser) {
+		p.validateUTF8 = true
+	}
+}
+
+// WithMultiValue is the option form of SetMultiValue(true).
+func WithMultiValue() Option {
+	return func(p *Parser) {
+		p.multiValue = true
+	}
+}
+
+// WithDuplicateStrategy is the option form of SetDuplicateStrategy.
+func WithDuplicateStrategy(strategy DuplicateStrategy) Option {
+	return func(p *Parser) {
+		p.duplicates = strategy
+	}
+}
+
+// WithDefaultSection is the option form of SetDefaultSection.
+func WithDefaultSection(name string) Option {
+	return func(p *Parser) {
+		p.defaultSection = name
+	}
+}
+
+// WithKeyNormalizer is the option form of SetKeyNormalizer.
+func WithKeyNormalizer(fn func(section, key string) string) Option {
+	return func(p *Parser) {
+		p.keyNormalizer = fn
+	}
+}
+
+// WithMaxLineLength is the option form of SetMaxLineLength.
+func WithMaxLineLength(n int) Option {
+	return func(p *Parser) {
+		p.maxLineLength = n
+	}
+}
+
+// WithCollectErrors is the option form of SetCollectErrors(true).
+func WithCollectErrors() Option {
+	return func(p *Parser) {
+		p.collectErrors = true
+	}
+}
+
+// WithIncludes enables include directives named "include". See
+// SetIncludeKey.
+func WithIncludes() Option {
+	return func(p *Parser) {
+		p.includeKey = defaultIncludeKey
+	}
+}
+
+// AllowEmptyValues controls whether keys may hold an empty value. When
+// disabled (the default), a "key=" line fails to parse and Set, AddKey and
+// UpdateKey reject an empty value with ErrValueIsEmpty. When enabled, both
+// are accepted and String writes them back as "key=", so content built with
+// Set always survives a save and reload.
+func (p *Parser) AllowEmptyValues(allow bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.allowEmptyValues = allow
+}
+
+// SetStrict enables or disables strict parsing. In strict mode a key/value
+// line before the first section header fails with ErrKeyOutsideSection
+// instead of being stored under the unnamed section. A line that is not a
+// comment, header or key/value pair fails with ErrMalformedLine instead of
+// being ignored, and empty or blank input fails with ErrEmptyString instead
+// of loading as an empty config. It is off by default.
+func (p *Parser) SetStrict(strict bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.strict = strict
+}
+
+// SetCommentPrefixes replaces the strings that start a comment, by default
+// ";" and "#". Empty prefixes, which would make every line a comment, are
+// ignored. Calling it with no prefixes disables comments entirely.
+func (p *Parser) SetCommentPrefixes(prefixes ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.commentPrefixes = commentPrefixes(prefixes)
+}
+
+// commentPrefixes copies prefixes without the empty ones. The result is
+// never nil, so an empty list still disables comments.
+func commentPrefixes(prefixes []string) []string {
+	kept := []string{}
+	for _, prefix := range prefixes {
+		if prefix != "" {
+			kept = append(kept, prefix)
+		}
+	}
+	return kept
+}
+
+// SetInlineComments enables or disables stripping of comments that follow a
+// value on the same line, as in "port=8080 ; dev only". An inline comment
+// must be preceded by whitespace, so "url=http://host/#anchor" keeps its
+// value intact. Comment characters inside a quoted value, as in
+// tag="#a ; b", are kept too. It is off by default.
+func (p *Parser) SetInlineComments(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.inlineComments = enabled
+}
+
+// SetCollectErrors enables or disables error collection. When enabled,
+// loading does not stop at the first bad line: every parse error is
+// gathered, the valid entries are still loaded, and the errors are returned
+// joined with errors.Join so errors.Is works for each of them. Keys below a
+// malformed section header are skipped until the next valid header. It is
+// off by default.
+func (p *Parser) SetCollectErrors(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.collectErrors = enabled
+}
+
+// SetOutputDelimiter sets the text String, WriteTo and SaveToFile put
+// between a key and its value, for example " = ". By default the separator
+// alone is written. The delimiter should contain the separator surrounded
+// only by spaces or tabs so the output can be loaded back; spacing around
+// the separator is always accepted when parsing. An empty delimiter
+// restores the default.
+func (p *Parser) SetOutputDelimiter(delim string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.outputDelimiter = delim
+}
+
+// SetIncludeKey enables include directives: when LoadFromFile meets a key
+// named key, it loads the file given as its value, relative to the
+// including file's directory, instead of storing the key. The included
+// file starts in the section the directive appears in, and its entries are
+// merged with the rest. A file that includes itself, directly or through
+// others, fails with ErrIncludeCycle. An empty key disables includes,
+// which is the default. Other loading methods have no file to resolve
+// against and store the key as usual.
+func (p *Parser) SetIncludeKey(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.includeKey = key
+}
+
+// SetPreserveComments enables or disables keeping comment lines when
+// loading. Each run of comment lines is attached to the section header or
+// key that follows it, and comments after the last entry are kept at the
+// end; String, WriteTo and SaveToFile then write them back in those
+// positions. Inline comments and blank lines are not kept. It is off by
+// default, in which case comments are discarded on load.
+func (p *Parser) SetPreserveComments(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.preserveComments = enabled
+}
+
+// SetValidateUTF8 enables or disables checking that loaded input is valid
+// UTF-8. When enabled, the first invalid line fails with a ParseError
+// wrapping ErrInvalidUTF8 whose column is the offending byte, which catches
+// binary or Latin-1 files loaded by mistake. It is off by default.
+func (p *Parser) SetValidateUTF8(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.validateUTF8 = enabled
+}
+
+// SetMultiValue enables or disables multi-valued keys. When enabled, a key
+// repeated within a section while loading, such as several "server=" lines,
+// keeps all of its values: GetValues returns them in order and String
+// writes one line per value. Get and the other accessors see the last
+// value, and setting the key through Set replaces all of them. When
+// disabled (the default), SetDuplicateStrategy decides which value wins.
+func (p *Parser) SetMultiValue(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.multiValue = enabled
+}
+
+// SetDuplicateStrategy sets what loading does with a key repeated within a
+// section: keep the last value (DuplicateLast, the default), keep the first
+// (DuplicateFirst) or fail with ErrDuplicateKey (DuplicateError). It has no
+// effect in multi-value mode, where every value is kept. Only repeats
+// within one file count: with LoadFiles, a later file always overrides.
+func (p *Parser) SetDuplicateStrategy(strategy DuplicateStrategy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.duplicates = strategy
+}
+
+// SetDefaultSection names a section, such as "DEFAULT", whose keys every
+// other section inherits: Get and the typed accessors fall back to it for a
+// key missing from the requested section. The inherited keys are not
+// copied, so they do not show up in GetSections, Keys or String under the
+// inheriting sections. An empty name, the default, disables inheritance.
+func (p *Parser) SetDefaultSection(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.defaultSection = name
+}
+
+// SetKeyNormalizer sets a function applied to every key as it is loaded,
+// for example to rename legacy keys such as "svr" to "server". It receives
+// the name of the enclosing section and the key as written, and the key it
+// returns is stored instead, so String writes the new names. Everything
+// else sees only the returned key: case-insensitive matching, duplicate
+// detection (a file holding both "svr" and "server" then has a duplicate),
+// and include directives. Returning "" fails with ErrKeyIsEmpty. Keys
+// added with Set and similar methods are not normalized. A nil fn removes
+// the normalizer.
+func (p *Parser) SetKeyNormalizer(fn func(section, key string) string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.keyNormalizer = fn
+}
+
+// SetAllowIndentation controls whether section headers and key lines may be
+// indented. When disallowed, an indented line fails with a ParseError
+// wrapping ErrUnexpectedIndentation that quotes the leading whitespace, so
+// accidentally nested entries and stray tabs are caught. Comments may
+// still be indented. Indentation is allowed by default.
+func (p *Parser) SetAllowIndentation(allow bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rejectIndentation = !allow
+}
+
+// SetLineEnding sets the line ending String, WriteTo and SaveToFile write:
+// "\n" (the default) or "\r\n". Any other value is rejected with
+// ErrInvalidLineEnding. Both endings are accepted when loading.
+func (p *Parser) SetLineEnding(ending string) error {
+	if ending != "\n" && ending != "\r\n" {
+		return fmt.Errorf("%w: %q", ErrInvalidLineEnding, ending)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lineEnding = ending
+	return nil
+}
+
+// SetMaxLineLength sets the longest line, in bytes, that LoadFromReader,
+// LoadFromFile and ReadFrom accept; longer lines fail with ErrLineTooLong.
+// The default is 1 MiB. A value of zero or less restores the default.
+func (p *Parser) SetMaxLineLength(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.maxLineLength = n
+}
+
+// prefixes returns the configured comment prefixes, falling back to the
+// defaults when none were set.
+func (s *settings) prefixes() []string {
+	if s.commentPrefixes == nil {
+		return defaultCommentPrefixes
+	}
+	return s.commentPrefixes
+}
+
+// seps returns the configured key/value separators, falling back to the
+// default when none were set.
+func (s *settings) seps() string {
+	if s.separators == "" {
+		return defaultSeparators
+	}
+	return s.separators
+}
+
+// delimiter returns the text written between keys and values, falling back
+// to the first separator when none was set.
+func (s *settings) delimiter() string {
+	if s.outputDelimiter == "" {
+		return s.seps()[:1]
+	}
+	return s.outputDelimiter
+}
+
+// lineLimit returns the configured maximum line length, falling back to the
+// default when none was set.
+func (s *settings) lineLimit() int {
+	if s.maxLineLength <= 0 {
+		return defaultMaxLineLength
+	}
+	return s.maxLineLength
+}
@@ -0,0 +1,482 @@
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestAllowEmptyValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		allow   bool
+		input   string
+		wantErr error
+		want    string
+	}{
+		{"disabled rejects key=", false, "[s]\nk=\n", ErrValueIsEmpty, ""},
+		{"disabled rejects blank value", false, "[s]\nk =   \n", ErrValueIsEmpty, ""},
+		{"enabled keeps key=", true, "[s]\nk=\n", nil, "[s]\nk=\n"},
+		{"enabled keeps blank value", true, "[s]\nk =   \nother=x\n", nil, "[s]\nk=\nother=x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.AllowEmptyValues(tt.allow)
+			err := p.LoadFromString(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromString() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got, err := p.Get("s", "k"); err != nil || got != "" {
+				t.Errorf("Get() = %q, %v, want empty value", got, err)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowEmptyValuesSetters(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		p := NewParser()
+		p.AllowEmptyValues(allow)
+		var wantErr error
+		if !allow {
+			wantErr = ErrValueIsEmpty
+		}
+		if err := p.Set("s", "k", ""); !errors.Is(err, wantErr) {
+			t.Fatalf("allow=%v: Set() error = %v, want %v", allow, err, wantErr)
+		}
+		if !allow {
+			continue
+		}
+		if err := p.UpdateKey("s", "k", ""); err != nil {
+			t.Errorf("UpdateKey() error = %v", err)
+		}
+		if err := p.AddKey("s", "other", ""); err != nil {
+			t.Errorf("AddKey() error = %v", err)
+		}
+		reloaded := NewParser(WithAllowEmptyValues())
+		if err := reloaded.LoadFromString(p.String()); err != nil {
+			t.Fatalf("reloading %q: %v", p.String(), err)
+		}
+		if !reloaded.Equal(p) {
+			t.Errorf("reloaded %v, want %v", reloaded.GetSections(), p.GetSections())
+		}
+	}
+}
+
+func TestStrictKeyOutsideSection(t *testing.T) {
+	const input = "# header\n\nglobal=yes\n[s]\nk=v\n"
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr error
+	}{
+		{"lenient", false, nil},
+		{"strict", true, ErrKeyOutsideSection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.SetStrict(tt.strict)
+			err := p.LoadFromString(input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromString() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var perr *ParseError
+				if !errors.As(err, &perr) || perr.Line != 3 {
+					t.Errorf("error = %#v, want a *ParseError on line 3", err)
+				}
+				return
+			}
+			if got, err := p.Get("", "global"); err != nil || got != "yes" {
+				t.Errorf("Get(\"\", global) = %q, %v, want yes", got, err)
+			}
+		})
+	}
+}
+
+func TestCommentPrefixes(t *testing.T) {
+	const input = "// header\n[s]\n// note\nk=a;b // trailing\n#tag=x\n;semi=y\n"
+	tests := []struct {
+		name     string
+		prefixes []string
+		key      string
+		want     string
+		wantErr  error
+	}{
+		{"semicolon in value is literal", []string{"//"}, "k", "a;b", nil},
+		{"hash key is literal", []string{"//"}, "#tag", "x", nil},
+		{"semicolon key is literal", []string{"//"}, ";semi", "y", nil},
+		{"defaults keep slashes", nil, "k", "a;b // trailing", nil},
+		{"defaults skip hash line", nil, "#tag", "", ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{withInlineComments()}
+			if tt.prefixes != nil {
+				opts = append(opts, WithCommentPrefixes(tt.prefixes...))
+			}
+			p := NewParser(opts...)
+			if err := p.LoadFromString(input); err != nil {
+				t.Fatalf("LoadFromString() error = %v", err)
+			}
+			got, err := p.Get("s", tt.key)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Get(s, %q) = %q, %v, want %q, %v", tt.key, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStrictMalformedLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantLine int
+	}{
+		{"outside a section", "justtext\n[s]\nk=v\n", 1},
+		{"inside a section", "[s]\nk=v\njusttext\n", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lenient := NewParser()
+			if err := lenient.LoadFromString(tt.input); err != nil {
+				t.Fatalf("lenient LoadFromString() error = %v", err)
+			}
+			if got, _ := lenient.Get("s", "k"); got != "v" {
+				t.Errorf("lenient Get(s, k) = %q, want v", got)
+			}
+
+			err := NewParser(WithStrict()).LoadFromString(tt.input)
+			if !errors.Is(err, ErrMalformedLine) {
+				t.Fatalf("strict LoadFromString() error = %v, want %v", err, ErrMalformedLine)
+			}
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Line != tt.wantLine || !strings.Contains(err.Error(), "justtext") {
+				t.Errorf("error = %v, want line %d mentioning the line content", err, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		input string
+		check func(p *Parser) error
+	}{
+		{
+			name:  "case-insensitive and separator",
+			opts:  []Option{WithCaseInsensitive(), WithSeparator(':')},
+			input: "[Server]\nHost: example.com\n",
+			check: func(p *Parser) error {
+				got, err := p.Get("SERVER", "host")
+				if err != nil || got != "example.com" {
+					return fmt.Errorf("Get(SERVER, host) = %q, %v", got, err)
+				}
+				if s := p.String(); s != "[Server]\nHost:example.com\n" {
+					return fmt.Errorf("String() = %q", s)
+				}
+				return nil
+			},
+		},
+		{
+			name:  "empty values and comment prefixes",
+			opts:  []Option{WithAllowEmptyValues(), WithCommentPrefixes("#")},
+			input: "[s]\n;k=\n# comment\n",
+			check: func(p *Parser) error {
+				got, err := p.Get("s", ";k")
+				if err != nil || got != "" {
+					return fmt.Errorf("Get(s, ;k) = %q, %v", got, err)
+				}
+				return nil
+			},
+		},
+		{
+			name:  "no options keeps the defaults",
+			input: "[s]\nk=v ; not stripped\n",
+			check: func(p *Parser) error {
+				if _, err := p.Get("S", "k"); !errors.Is(err, ErrSectionNotFound) {
+					return fmt.Errorf("Get(S, k) error = %v", err)
+				}
+				if err := p.Set("s", "e", ""); !errors.Is(err, ErrValueIsEmpty) {
+					return fmt.Errorf("Set() empty value error = %v", err)
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.opts...)
+			if err := p.LoadFromString(tt.input); err != nil {
+				t.Fatalf("LoadFromString() error = %v", err)
+			}
+			if err := tt.check(p); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestNewParserWithOptions(t *testing.T) {
+	p := NewParserWithOptions(WithDelimiters('=', ':'), WithStrict())
+	if err := p.LoadFromString("[s]\na=1\nb: 2\n"); err != nil {
+		t.Fatalf("LoadFromString() error = %v", err)
+	}
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		if got, err := p.Get("s", key); err != nil || got != want {
+			t.Errorf("Get(s, %s) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+	if s := p.String(); s != "[s]\na=1\nb=2\n" {
+		t.Errorf("String() = %q, want the first delimiter used", s)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{"key outside section", "k: v\n", ErrKeyOutsideSection},
+		{"malformed line", "[s]\njunk\n", ErrMalformedLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := p.LoadFromString(tt.input); !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoadFromString() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	const input = "[good]\nk=v\n\n[bad]\n=orphan\nok=1\nempty=\n\n[other]\nx=y\n"
+	p := NewParser(WithCollectErrors())
+	err := p.LoadFromString(input)
+	for _, want := range []error{ErrKeyIsEmpty, ErrValueIsEmpty} {
+		if !errors.Is(err, want) {
+			t.Errorf("LoadFromString() error = %v, want it to include %v", err, want)
+		}
+	}
+	for _, want := range []string{"line 5:", "line 7:"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadFromString() error = %v, want it to mention %q", err, want)
+		}
+	}
+	for _, kv := range [][3]string{{"good", "k", "v"}, {"bad", "ok", "1"}, {"other", "x", "y"}} {
+		if got, err := p.Get(kv[0], kv[1]); err != nil || got != kv[2] {
+			t.Errorf("Get(%q, %q) = %q, %v, want %q", kv[0], kv[1], got, err, kv[2])
+		}
+	}
+
+	if err := NewParser().LoadFromString(input); !errors.Is(err, ErrKeyIsEmpty) || errors.Is(err, ErrValueIsEmpty) {
+		t.Errorf("without collecting, error = %v, want only the first failure", err)
+	}
+}
+
+func TestOutputDelimiter(t *testing.T) {
+	tests := []struct {
+		name  string
+		delim string
+		want  string
+	}{
+		{"default", "", "[s]\na=1\nb=2\n"},
+		{"spaced", " = ", "[s]\na = 1\nb = 2\n"},
+		{"tab", "\t=\t", "[s]\na\t=\t1\nb\t=\t2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\na=1\nb = 2\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.SetOutputDelimiter(tt.delim)
+			got := p.String()
+			if got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+			reloaded, err := Parse(got)
+			if err != nil {
+				t.Fatalf("reloading %q: %v", got, err)
+			}
+			if !reloaded.Equal(p) {
+				t.Errorf("reloaded %v, want %v", reloaded.GetSections(), p.GetSections())
+			}
+		})
+	}
+}
+
+func TestPropertiesDialect(t *testing.T) {
+	const input = "! generated file\n# database settings\n[db]\nhost:localhost\nport = 5432\nurl=jdbc:postgresql://localhost\n;user=admin\n"
+	p := NewParser(WithPropertiesDialect())
+	if err := p.LoadFromString(input); err != nil {
+		t.Fatalf("LoadFromString() error = %v", err)
+	}
+	want := map[string]string{
+		"host":  "localhost",
+		"port":  "5432",
+		"url":   "jdbc:postgresql://localhost",
+		";user": "admin",
+	}
+	got := p.GetSections()
+	if len(got) != 1 || !maps.Equal(got["db"], want) {
+		t.Errorf("sections = %v, want db = %v", got, want)
+	}
+	if s := p.String(); !strings.Contains(s, "\nhost=localhost\n") {
+		t.Errorf("String() = %q, want keys written with =", s)
+	}
+}
+
+func TestKeyNormalizer(t *testing.T) {
+	legacy := map[string]string{"svr": "server", "prt": "port", "usr": "user"}
+	migrate := func(section, key string) string {
+		if section == "db" {
+			if renamed, ok := legacy[key]; ok {
+				return renamed
+			}
+		}
+		return key
+	}
+	tests := []struct {
+		name    string
+		opts    []Option
+		input   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "legacy keys renamed",
+			opts:  []Option{WithKeyNormalizer(migrate)},
+			input: "[db]\nsvr=a\nprt=5432\nusr=admin\nname=x\n[web]\nsvr=kept\n",
+			want:  "[db]\nname=x\nport=5432\nserver=a\nuser=admin\n\n[web]\nsvr=kept\n",
+		},
+		{
+			name:    "old and new spelling collide",
+			opts:    []Option{WithKeyNormalizer(migrate), WithDuplicateStrategy(DuplicateError)},
+			input:   "[db]\nsvr=a\nserver=b\n",
+			wantErr: ErrDuplicateKey,
+		},
+		{
+			name:    "case folding sees the normalized key",
+			opts:    []Option{WithKeyNormalizer(migrate), WithCaseInsensitive()},
+			input:   "[db]\nsvr=a\nSERVER=b\n",
+			wantErr: ErrDuplicateKey,
+		},
+		{
+			name:    "empty result",
+			opts:    []Option{WithKeyNormalizer(func(string, string) string { return "" })},
+			input:   "[db]\nk=v\n",
+			wantErr: ErrKeyIsEmpty,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.opts...)
+			err := p.LoadFromString(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromString() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyNormalizerNotAppliedToSet(t *testing.T) {
+	p := NewParser(WithKeyNormalizer(func(string, string) string { return "renamed" }))
+	if err := p.Set("s", "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := p.Get("s", "k"); err != nil || got != "v" {
+		t.Errorf("Get(s, k) = %q, %v, want v", got, err)
+	}
+	p.SetKeyNormalizer(nil)
+	if err := p.LoadFromString("[s]\nk=v\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Get("s", "k"); err != nil {
+		t.Errorf("Get(s, k) after removing the normalizer error = %v", err)
+	}
+}
+
+func TestAllowIndentation(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantLine int
+		wantMsg  string
+	}{
+		{"spaces", "[s]\n  k=v\n", 2, `"  "`},
+		{"tab", "[s]\nk=v\n\tnested=1\n", 3, `"\t"`},
+		{"header", "[s]\nk=v\n [t]\n", 3, `" "`},
+		{"comment allowed", "[s]\n  ; note\nk=v\n", 0, ""},
+		{"blank line allowed", "[s]\n   \nk=v\n", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewParser().LoadFromString(tt.input); err != nil {
+				t.Fatalf("by default LoadFromString() error = %v", err)
+			}
+			p := NewParser()
+			p.SetAllowIndentation(false)
+			err := p.LoadFromString(tt.input)
+			if tt.wantLine == 0 {
+				if err != nil {
+					t.Errorf("LoadFromString() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnexpectedIndentation) {
+				t.Fatalf("LoadFromString() error = %v, want %v", err, ErrUnexpectedIndentation)
+			}
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Line != tt.wantLine || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %v, want line %d quoting %s", err, tt.wantLine, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestEmptyCommentPrefixIgnored(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() *Parser
+	}{
+		{"option, only empty", func() *Parser { return NewParser(WithCommentPrefixes("")) }},
+		{"option, empty among others", func() *Parser { return NewParser(WithCommentPrefixes("", "#")) }},
+		{"setter", func() *Parser {
+			p := NewParser()
+			p.SetCommentPrefixes("", "#")
+			return p
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.build()
+			if err := p.LoadFromString("# note\n[s]\nk=v\n"); err != nil {
+				t.Fatalf("LoadFromString() error = %v", err)
+			}
+			if got, err := p.Get("s", "k"); err != nil || got != "v" {
+				t.Errorf("Get(s, k) = %q, %v, want v", got, err)
+			}
+			if got := p.String(); got != "[s]\nk=v\n" {
+				t.Errorf("String() = %q, want the key unquoted", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,566 @@
+package iniparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lineParser builds parsed data from INI input fed to it one line at a time,
+// so the same rules apply whether the input is a string or a stream.
+type lineParser struct {
+	settings
+
+	data    map[string]map[string]string
+	section string
+
+	// comments and pending are only filled when comments are preserved;
+	// pending holds the comment lines not yet attached to an entry.
+	comments map[string]map[string][]string
+	pending  []string
+
+	// values holds every value of keys repeated in multi-value mode, and
+	// raw the text values were read from where it differs from them.
+	values map[string]map[string][]string
+	raw    map[string]map[string]string
+
+	lineNum  int
+	nonBlank bool
+
+	// When collectWarnings is set, recoverable problems are recorded in
+	// warnings and the offending line is skipped instead of failing.
+	collectWarnings bool
+	warnings        []Warning
+
+	// badSection is set after a malformed section header so that, when
+	// errors are collected, the keys below it are skipped rather than
+	// filed under the previous section.
+	badSection bool
+	errs       []error
+
+	// file is the path of the file being read, if any. Include directives
+	// are resolved relative to it, and including holds the files currently
+	// being read so that include cycles can be detected.
+	file      string
+	including []string
+
+	// keyLines records the line each key was first seen on, indexed by
+	// section and key joined with a NUL byte, to report duplicate keys. It
+	// only covers the file being read, so keys from earlier files passed to
+	// LoadFiles can be overridden.
+	keyLines map[string]int
+}
+
+// newLineParser returns a lineParser using a snapshot of p's settings. It
+// must be called with p.mu held.
+func (p *Parser) newLineParser() *lineParser {
+	return &lineParser{
+		settings: p.settings,
+		data:     make(map[string]map[string]string),
+		keyLines: make(map[string]int),
+	}
+}
+
+// parseLines parses every element of lines. It must be called with p.mu
+// held.
+func (p *Parser) parseLines(lines []string) (*document, error) {
+	lp := p.newLineParser()
+	for _, line := range lines {
+		if err := lp.parseLine(line); err != nil {
+			return nil, err
+		}
+	}
+	return lp.result()
+}
+
+// parseReader parses r line by line without buffering the whole input.
+// file is the path r was opened from, or "" if it is not a file; include
+// directives are only resolved for files.
+func (p *Parser) parseReader(r io.Reader, file string) (*document, error) {
+	p.mu.RLock()
+	lp := p.newLineParser()
+	p.mu.RUnlock()
+
+	if file != "" {
+		lp.file = absPath(file)
+		lp.including = []string{lp.file}
+	}
+	if err := lp.scan(r); err != nil {
+		return nil, err
+	}
+	return lp.result()
+}
+
+// parseFiles parses the files at paths in order into one document, so a key
+// set in a later file overrides the same key from an earlier one; duplicate
+// detection only applies within a file. Each file starts outside any
+// section. Errors are prefixed with the offending path.
+// When optional is set, files that do not exist are skipped.
+func (p *Parser) parseFiles(paths []string, optional bool) (*document, error) {
+	p.mu.RLock()
+	lp := p.newLineParser()
+	p.mu.RUnlock()
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			if optional && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		lp.file = absPath(path)
+		lp.including = []string{lp.file}
+		lp.section, lp.lineNum, lp.badSection = "", 0, false
+		lp.keyLines = make(map[string]int)
+		collected := len(lp.errs)
+		err = lp.scan(f)
+		f.Close()
+		for i := collected; i < len(lp.errs); i++ {
+			lp.errs[i] = fmt.Errorf("%s: %w", path, lp.errs[i])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return lp.result()
+}
+
+// scan feeds every line of r to lp.
+func (lp *lineParser) scan(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	limit := lp.lineLimit()
+	scanner.Buffer(make([]byte, 0, min(limit, 64*1024)), limit)
+	for scanner.Scan() {
+		if err := lp.parseLine(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			detail := fmt.Sprintf("longer than %d bytes", limit)
+			return newParseError(lp.lineNum+1, 1, ErrLineTooLong, detail)
+		}
+		return err
+	}
+	return nil
+}
+
+// include parses the file named by an include directive into lp's data,
+// starting in the current section. name is resolved relative to the
+// directory of the including file.
+func (lp *lineParser) include(name string, col int) error {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(lp.file), path)
+	}
+	path = absPath(path)
+	if slices.Contains(lp.including, path) {
+		return newParseError(lp.lineNum, col, ErrIncludeCycle, strconv.Quote(name))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	child := &lineParser{
+		settings:        lp.settings,
+		data:            lp.data,
+		comments:        lp.comments,
+		pending:         lp.pending,
+		values:          lp.values,
+		raw:             lp.raw,
+		keyLines:        lp.keyLines,
+		section:         lp.section,
+		collectWarnings: lp.collectWarnings,
+		file:            path,
+		including:       append(slices.Clip(lp.including), path),
+	}
+	err = child.scan(f)
+	lp.comments, lp.pending, lp.values, lp.raw = child.comments, child.pending, child.values, child.raw
+	lp.warnings = append(lp.warnings, child.warnings...)
+	for _, e := range child.errs {
+		lp.errs = append(lp.errs, fmt.Errorf("%s: %w", path, e))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// absPath returns path made absolute, or path itself if that fails.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// result returns the parsed document. Blank input yields no data and, in
+// strict mode only, ErrEmptyString. When errors were collected, the data
+// parsed from the valid lines is returned together with all of them joined.
+func (lp *lineParser) result() (*document, error) {
+	if !lp.nonBlank && lp.strict {
+		return nil, ErrEmptyString
+	}
+	doc := &document{
+		parsedData:       lp.data,
+		comments:         lp.comments,
+		trailingComments: lp.pending,
+		values:           lp.values,
+		raw:              lp.raw,
+	}
+	return doc, errors.Join(lp.errs...)
+}
+
+// parseLine parses the next line of input.
+func (lp *lineParser) parseLine(raw string) error {
+	section := lp.section
+	err := lp.handleLine(raw)
+	var pe *ParseError
+	// Errors from included files are wrapped and already describe their
+	// own line, so only a bare ParseError is annotated.
+	if e, ok := err.(*ParseError); ok {
+		e.Section, e.Raw = section, raw
+	}
+	if err != nil && lp.collectWarnings && errors.As(err, &pe) && isRecoverable(pe.Err) {
+		lp.warn("%s", pe.Msg)
+		return nil
+	}
+	if err != nil && lp.collectErrors && errors.As(err, &pe) {
+		lp.errs = append(lp.errs, err)
+		return nil
+	}
+	return err
+}
+
+// isRecoverable reports whether a parse failure only affects its own line,
+// so parsing can safely go on without it.
+func isRecoverable(err error) bool {
+	return errors.Is(err, ErrKeyIsEmpty) ||
+		errors.Is(err, ErrValueIsEmpty) ||
+		errors.Is(err, ErrDuplicateKey) ||
+		errors.Is(err, ErrKeyOutsideSection) ||
+		errors.Is(err, ErrMalformedLine)
+}
+
+// warn records a warning for the current line when warnings are collected.
+func (lp *lineParser) warn(format string, args ...any) {
+	if lp.collectWarnings {
+		lp.warnings = append(lp.warnings, Warning{Line: lp.lineNum, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (lp *lineParser) handleLine(raw string) error {
+	lp.lineNum++
+	if lp.lineNum == 1 {
+		raw = strings.TrimPrefix(raw, "\uFEFF")
+	}
+	if lp.validateUTF8 && !utf8.ValidString(raw) {
+		i := invalidUTF8Index(raw)
+		return newParseError(lp.lineNum, i+1, ErrInvalidUTF8, fmt.Sprintf("byte 0x%02x", raw[i]))
+	}
+	line := strings.TrimSpace(raw)
+	if line != "" {
+		lp.nonBlank = true
+	}
+	// col is the 1-based column of the first non-blank character.
+	col := len(raw) - len(strings.TrimLeftFunc(raw, unicode.IsSpace)) + 1
+
+	switch {
+	case line == "":
+		return nil
+
+	case lp.isComment(line):
+		lp.keepComment(line)
+		return nil
+
+	case col > 1 && lp.rejectIndentation:
+		return newParseError(lp.lineNum, 1, ErrUnexpectedIndentation, "indented by "+strconv.Quote(raw[:col-1]))
+
+	case strings.HasPrefix(line, "["):
+		lp.badSection = true
+		// The header may be followed by a comment: "[name] ; note".
+		name, end := parseHeader(line)
+		if end < 0 {
+			return newParseError(lp.lineNum, col, ErrMalformedSectionHeader, strconv.Quote(line))
+		}
+		if rest := strings.TrimSpace(line[end+1:]); rest != "" && !lp.isComment(rest) {
+			return newParseError(lp.lineNum, col, ErrMalformedSectionHeader, strconv.Quote(line))
+		}
+		section := strings.TrimSpace(name)
+		if section == "" {
+			return newParseError(lp.lineNum, col, ErrSectionIsEmpty, "")
+		}
+		if name, ok := lookupName(lp.data, section, lp.caseInsensitive); ok {
+			section = name
+		} else {
+			lp.data[section] = make(map[string]string)
+		}
+		lp.section = section
+		lp.badSection = false
+		lp.attachComments(section, "")
+
+	// A key line may end in "]" too, as in "pattern=[a-z]", so only a line
+	// without a separator is taken for a header missing its "[".
+	case strings.HasSuffix(line, "]") && !strings.ContainsAny(line, lp.seps()):
+		lp.badSection = true
+		return newParseError(lp.lineNum, col, ErrMalformedSectionHeader, strconv.Quote(line))
+
+	case lp.badSection:
+		return nil
+
+	case strings.ContainsAny(line, lp.seps()):
+		if lp.strict && lp.section == "" {
+			return newParseError(lp.lineNum, col, ErrKeyOutsideSection, strconv.Quote(line))
+		}
+		key, value := splitKeyValue(line, lp.seps())
+		raw := strings.TrimSpace(value)
+		if lp.inlineComments {
+			value = lp.stripInlineComment(value)
+		}
+		value = unquote(strings.TrimSpace(value))
+		if lp.keyNormalizer != nil && key != "" {
+			key = lp.keyNormalizer(lp.section, key)
+		}
+		if key == "" {
+			return newParseError(lp.lineNum, col, ErrKeyIsEmpty, "")
+		}
+		if value == "" && !lp.allowEmptyValues {
+			return newParseError(lp.lineNum, col+strings.IndexAny(line, lp.seps())+1, ErrValueIsEmpty, strconv.Quote(key))
+		}
+		if lp.isInclude(key) {
+			return lp.include(value, col)
+		}
+		keys, ok := lp.data[lp.section]
+		if !ok {
+			keys = make(map[string]string)
+			lp.data[lp.section] = keys
+		}
+		name, exists := lookupName(keys, key, lp.caseInsensitive)
+		if _, seen := lp.keyLines[lp.section+"\x00"+name]; exists && !seen {
+			// The key comes from an earlier file passed to LoadFiles, which
+			// this one overrides rather than duplicates.
+			lp.forget(lp.section, name)
+			name, exists = key, false
+		}
+		if exists && name != key {
+			detail := fmt.Sprintf("%q collides with %q from line %d in section %q",
+				key, name, lp.keyLines[lp.section+"\x00"+name], lp.section)
+			return newParseError(lp.lineNum, col, ErrDuplicateKey, detail)
+		}
+		switch {
+		case exists && lp.multiValue:
+			lp.appendValue(lp.section, name, keys[name], value)
+		case exists && lp.duplicates == DuplicateError:
+			detail := fmt.Sprintf("%q already set on line %d in section %q",
+				key, lp.keyLines[lp.section+"\x00"+name], lp.section)
+			return newParseError(lp.lineNum, col, ErrDuplicateKey, detail)
+		case exists && lp.duplicates == DuplicateFirst:
+			lp.warn("duplicate key %q in section %q, keeping the first value", key, lp.section)
+			lp.attachComments(lp.section, name)
+			return nil
+		case exists:
+			lp.warn("duplicate key %q in section %q, keeping the last value", key, lp.section)
+		default:
+			lp.keyLines[lp.section+"\x00"+key] = lp.lineNum
+		}
+		keys[name] = value
+		lp.keepRaw(lp.section, name, raw, value)
+		lp.attachComments(lp.section, name)
+
+	case lp.strict:
+		return newParseError(lp.lineNum, col, ErrMalformedLine, strconv.Quote(line))
+
+	default:
+		lp.warn("ignored line %q: not a comment, section header or key/value pair", line)
+	}
+	return nil
+}
+
+// forget removes key from section along with its values, raw text and
+// comments.
+func (lp *lineParser) forget(section, key string) {
+	delete(lp.data[section], key)
+	delete(lp.values[section], key)
+	delete(lp.raw[section], key)
+	delete(lp.comments[section], key)
+}
+
+// invalidUTF8Index returns the byte index of the first invalid UTF-8
+// sequence in s, or -1 if s is valid.
+func invalidUTF8Index(s string) int {
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isInclude reports whether key is the include directive of a file being
+// read with includes enabled.
+func (lp *lineParser) isInclude(key string) bool {
+	if lp.file == "" || lp.includeKey == "" {
+		return false
+	}
+	return key == lp.includeKey || lp.caseInsensitive && strings.EqualFold(key, lp.includeKey)
+}
+
+// splitKeyValue splits a key line on its first separator (any byte of
+// seps). A key wrapped in double quotes may itself contain separators and
+// surrounding spaces, as in `"a=b" = c`; the quotes are removed and the key
+// kept verbatim, except that `\"` stands for a double quote and `\\` for a
+// backslash. Unquoted keys are trimmed.
+func splitKeyValue(line, seps string) (key, value string) {
+	if key, end := parseQuotedKey(line); end >= 0 {
+		rest := strings.TrimSpace(line[end+1:])
+		if rest != "" && strings.IndexByte(seps, rest[0]) >= 0 {
+			return key, rest[1:]
+		}
+	}
+	i := strings.IndexAny(line, seps)
+	return strings.TrimSpace(line[:i]), line[i+1:]
+}
+
+// parseQuotedKey returns the key of a line starting with a double quote and
+// the index of its closing quote, or -1 if the line does not start with a
+// quoted key.
+func parseQuotedKey(line string) (key string, end int) {
+	if !strings.HasPrefix(line, `"`) {
+		return "", -1
+	}
+	var sb strings.Builder
+	for i := 1; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line) && (line[i+1] == '"' || line[i+1] == '\\'):
+			i++
+			sb.WriteByte(line[i])
+		case c == '"':
+			return sb.String(), i
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", -1
+}
+
+// formatKey quotes key when it could not be read back unquoted: when it
+// holds a separator, has surrounding spaces, or starts with something that
+// would make the line a section header, a comment or a quoted key, or with
+// a byte-order mark that would be dropped from the first line.
+func (s *settings) formatKey(key string) string {
+	if strings.ContainsAny(key, s.seps()) || strings.TrimSpace(key) != key ||
+		strings.HasPrefix(key, "[") || strings.HasPrefix(key, `"`) || s.isComment(key) ||
+		strings.HasPrefix(key, "\uFEFF") {
+		return `"` + quoteEscaper.Replace(key) + `"`
+	}
+	return key
+}
+
+var (
+	quoteEscaper   = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	quoteUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+)
+
+// parseHeader returns the section name of a header line starting with "["
+// and the index of its closing "]", or -1 if there is none. Inside the
+// name, `\]` stands for "]" when another "]" follows it; otherwise the
+// backslash is kept and the "]" closes the header, so "[dir\]" names the
+// section `dir\`. Other backslashes are kept as they are.
+func parseHeader(line string) (name string, end int) {
+	var sb strings.Builder
+	for i := 1; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line) && line[i+1] == ']' && strings.Contains(line[i+2:], "]"):
+			i++
+			sb.WriteByte(line[i])
+		case c == ']':
+			return sb.String(), i
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", -1
+}
+
+// formatSection returns the header line for name, escaping each "]" in it
+// as parseHeader expects.
+func formatSection(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", `\]`) + "]"
+}
+
+// unquote removes the double quotes around a value that starts and ends
+// with one, so that `"  padded "` or `"a ; b"` keep their spaces and
+// comment characters. Inside them `\"` and `\\` stand for `"` and `\`, as
+// in a quoted key; other backslashes and quotes are left alone.
+func unquote(value string) string {
+	if isQuoted(value) {
+		return quoteUnescaper.Replace(value[1 : len(value)-1])
+	}
+	return value
+}
+
+// isQuoted reports whether value starts and ends with a double quote.
+func isQuoted(value string) bool {
+	return len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"'
+}
+
+// formatValue quotes value when it could not be read back unquoted: when
+// it has surrounding spaces, is itself quoted, or holds something that
+// would be taken for an inline comment. Quotes and backslashes inside it
+// are escaped as unquote expects.
+func (s *settings) formatValue(value string) string {
+	if strings.TrimSpace(value) != value || isQuoted(value) ||
+		s.inlineComments && s.stripInlineComment(value) != value {
+		return `"` + quoteEscaper.Replace(value) + `"`
+	}
+	return value
+}
+
+// isComment reports whether line, already trimmed, starts with a comment
+// prefix.
+func (s *settings) isComment(line string) bool {
+	for _, prefix := range s.prefixes() {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripInlineComment cuts value at the first comment prefix that starts the
+// value or follows whitespace. Comment characters between double quotes are
+// part of the value, and there `\"` does not close the quotes.
+func (s *settings) stripInlineComment(value string) string {
+	quoted := false
+	for i := 0; i < len(value); i++ {
+		switch {
+		case quoted && value[i] == '\\' && i+1 < len(value) && (value[i+1] == '"' || value[i+1] == '\\'):
+			i++
+			continue
+		case value[i] == '"':
+			quoted = !quoted
+		}
+		if quoted || i > 0 && value[i-1] != ' ' && value[i-1] != '\t' {
+			continue
+		}
+		if s.isComment(value[i:]) {
+			return value[:i]
+		}
+	}
+	return value
+}
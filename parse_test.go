@@ -0,0 +1,596 @@
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateStrategy(t *testing.T) {
+	const input = "[s]\nk=1\nother=x\nk=2\n"
+	tests := []struct {
+		name     string
+		strategy DuplicateStrategy
+		want     string
+		wantErr  error
+	}{
+		{"last", DuplicateLast, "2", nil},
+		{"first", DuplicateFirst, "1", nil},
+		{"error", DuplicateError, "", ErrDuplicateKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(WithDuplicateStrategy(tt.strategy))
+			err := p.LoadFromString(input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromString() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				var pe *ParseError
+				if !errors.As(err, &pe) || pe.Line != 4 {
+					t.Errorf("LoadFromString() error = %v, want a ParseError on line 4", err)
+				}
+				return
+			}
+			if got, _ := p.Get("s", "k"); got != tt.want {
+				t.Errorf("Get() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateStrategySetter(t *testing.T) {
+	p := NewParser()
+	p.SetDuplicateStrategy(DuplicateError)
+	if err := p.LoadFromString("[s]\nk=1\nk=2\n"); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("LoadFromString() error = %v, want %v", err, ErrDuplicateKey)
+	}
+	p.SetDuplicateStrategy(DuplicateLast)
+	if err := p.LoadFromString("[s]\nk=1\nk=2\n"); err != nil {
+		t.Fatalf("LoadFromString() error = %v", err)
+	}
+}
+
+func TestKeyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		key  string
+	}{
+		{"plain", nil, "name"},
+		{"separator", nil, "a=b"},
+		{"surrounding spaces", nil, " padded "},
+		{"hash comment prefix", nil, "#k"},
+		{"semicolon comment prefix", nil, ";k"},
+		{"custom comment prefix", []Option{WithCommentPrefixes("//")}, "//k"},
+		{"section header", nil, "[x]"},
+		{"opening bracket", nil, "[x"},
+		{"leading quote", nil, `"x`},
+		{"embedded quote with separator", nil, `a"b=c`},
+		{"embedded quote", nil, `a"b`},
+		{"backslash", nil, `C:\tmp`},
+		{"backslash and quote", nil, `"a\"b\`},
+		{"properties separator", []Option{WithPropertiesDialect()}, "a:b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.opts...)
+			if err := p.Set("s", tt.key, "v"); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			reloaded := NewParser(tt.opts...)
+			if err := reloaded.LoadFromString(p.String()); err != nil {
+				t.Fatalf("reload of %q error = %v", p.String(), err)
+			}
+			if got := reloaded.GetSections()["s"]; len(got) != 1 || got[tt.key] != "v" {
+				t.Errorf("reload of %q = %q, want key %q", p.String(), got, tt.key)
+			}
+		})
+	}
+}
+
+func TestQuotedKey(t *testing.T) {
+	tests := []struct {
+		line string
+		key  string
+	}{
+		{`"a=b" = c`, "a=b"},
+		{`"a\"b" = c`, `a"b`},
+		{`"a\\b" = c`, `a\b`},
+		{`"a\b" = c`, `a\b`},
+		{`"a"b=c`, `"a"b`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			p, err := Parse("[s]\n" + tt.line)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if _, err := p.Get("s", tt.key); err != nil {
+				t.Errorf("keys = %v, want %q", p.GetSections()["s"], tt.key)
+			}
+		})
+	}
+}
+
+func TestSectionHeaderEscapes(t *testing.T) {
+	tests := []struct {
+		header string
+		name   string
+	}{
+		{`[dir\]`, `dir\`},
+		{`[C:\tmp]`, `C:\tmp`},
+		{`[C:\\tmp]`, `C:\\tmp`},
+		{`[a\]b]`, "a]b"},
+		{`[a\\]b]`, `a\]b`},
+		{`[a\] ; note`, `a\`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			p, err := Parse(tt.header + "\nk=v")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := p.GetSectionNames(); len(got) != 1 || got[0] != tt.name {
+				t.Errorf("sections = %q, want %q", got, tt.name)
+			}
+		})
+	}
+}
+
+func TestSectionNameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{`C:\tmp`, `[C:\tmp]`},
+		{`dir\`, `[dir\]`},
+		{"a]b", `[a\]b]`},
+		{`a\]b`, `[a\\]b]`},
+		{`x]\`, `[x\]\]`},
+		{`]]`, `[\]\]]`},
+		{"a;b", "[a;b]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			if err := p.Set(tt.name, "k", "v"); err != nil {
+				t.Fatal(err)
+			}
+			out := p.String()
+			if header, _, _ := strings.Cut(out, "\n"); header != tt.header {
+				t.Errorf("header = %q, want %q", header, tt.header)
+			}
+			reloaded, err := Parse(out)
+			if err != nil {
+				t.Fatalf("reload of %q error = %v", out, err)
+			}
+			if !reloaded.Equal(p) {
+				t.Errorf("reload of %q gave sections %q", out, reloaded.GetSectionNames())
+			}
+		})
+	}
+}
+
+// sampleConfig is a small document exercising most of the syntax.
+const sampleConfig = `; last modified 1 April 2001 by John Doe
+global = yes
+
+[owner]
+name = John Doe
+organization = Acme Widgets Inc.
+
+[database]
+; use IP address in case network name resolution is not working
+server = 192.0.2.62
+port = 143
+file = "payroll.dat"
+pattern = [a-z]*
+
+[ spaced name ]
+key with spaces = value with spaces
+empty =
+`
+
+func TestLoadFromReaderMatchesLoadFromString(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		input string
+	}{
+		{"sample", []Option{WithAllowEmptyValues()}, sampleConfig},
+		{"sample with comments", []Option{WithAllowEmptyValues(), WithPreserveComments()}, sampleConfig},
+		{"crlf", []Option{WithAllowEmptyValues()}, strings.ReplaceAll(sampleConfig, "\n", "\r\n")},
+		{"no trailing newline", nil, "[s]\nk=v"},
+		{"byte-order mark", nil, "\uFEFF[s]\nk=v\n"},
+		{"multi-value", []Option{WithMultiValue()}, "[s]\nk=1\nk=2\n"},
+		{"large", nil, largeConfig(100, 50)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fromString := NewParser(tt.opts...)
+			if err := fromString.LoadFromString(tt.input); err != nil {
+				t.Fatalf("LoadFromString() error = %v", err)
+			}
+			fromReader := NewParser(tt.opts...)
+			if err := fromReader.LoadFromReader(strings.NewReader(tt.input)); err != nil {
+				t.Fatalf("LoadFromReader() error = %v", err)
+			}
+			if !fromReader.Equal(fromString) {
+				t.Errorf("LoadFromReader() loaded %v, LoadFromString() %v", fromReader.GetSections(), fromString.GetSections())
+			}
+			if got, want := fromReader.String(), fromString.String(); got != want {
+				t.Errorf("String() after LoadFromReader() = %q, after LoadFromString() = %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadFromReaderMatchesLoadFromStringErrors(t *testing.T) {
+	for _, input := range []string{"[s]\nk=v\n[broken\n", "[s]\n=v\n", ""} {
+		errString := NewParser(WithStrict()).LoadFromString(input)
+		errReader := NewParser(WithStrict()).LoadFromReader(strings.NewReader(input))
+		if errString == nil || errReader == nil || errString.Error() != errReader.Error() {
+			t.Errorf("input %q: LoadFromString() error = %v, LoadFromReader() error = %v", input, errString, errReader)
+		}
+	}
+}
+
+// largeConfig returns a document with the given number of sections, each
+// holding keysPerSection keys.
+func largeConfig(sections, keysPerSection int) string {
+	var sb strings.Builder
+	for i := range sections {
+		fmt.Fprintf(&sb, "[section%d]\n", i)
+		for j := range keysPerSection {
+			fmt.Fprintf(&sb, "key%d = value %d of section %d\n", j, j, i)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// BenchmarkLoadReadAll measures the approach LoadFromReader replaced:
+// reading the whole input into memory and splitting it into lines.
+func BenchmarkLoadReadAll(b *testing.B) {
+	data := largeConfig(1000, 100)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for range b.N {
+		content, err := io.ReadAll(strings.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := NewParser().LoadFromString(string(content)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadFromReader(b *testing.B) {
+	data := largeConfig(1000, 100)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for range b.N {
+		if err := NewParser().LoadFromReader(strings.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSectionHeaderTrailingComment(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		section string
+	}{
+		{"semicolon", "[database] ; production only", "database"},
+		{"hash", "[database] # production only", "database"},
+		{"no space", "[database];note", "database"},
+		{"bracket in comment", "[database] ; see [other]", "database"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.header + "\nport=5432\n")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got, err := p.Get(tt.section, "port"); err != nil || got != "5432" {
+				t.Errorf("Get(%q, port) = %q, %v, want 5432", tt.section, got, err)
+			}
+			if got := p.GetSectionNames(); len(got) != 1 {
+				t.Errorf("sections = %q, want only %q", got, tt.section)
+			}
+		})
+	}
+}
+
+func TestSectionHeaderTrailingGarbage(t *testing.T) {
+	if _, err := Parse("[database] production\nport=5432\n"); !errors.Is(err, ErrMalformedSectionHeader) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrMalformedSectionHeader)
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	const content = "[owner]\nname=John\n"
+	bom := string([]byte{0xEF, 0xBB, 0xBF})
+	loaders := []struct {
+		name string
+		load func(p *Parser, s string) error
+	}{
+		{"LoadFromString", func(p *Parser, s string) error { return p.LoadFromString(s) }},
+		{"LoadFromReader", func(p *Parser, s string) error { return p.LoadFromReader(strings.NewReader(s)) }},
+		{"LoadFromFile", func(p *Parser, s string) error { return p.LoadFromFile(writeFiles(t, s)[0]) }},
+	}
+	for _, tt := range loaders {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			if err := tt.load(p, bom+content); err != nil {
+				t.Fatalf("load error = %v", err)
+			}
+			if got, err := p.Get("owner", "name"); err != nil || got != "John" {
+				t.Errorf("Get(owner, name) = %q, %v, want John", got, err)
+			}
+			if got := p.String(); got != content {
+				t.Errorf("String() = %q, want %q", got, content)
+			}
+		})
+	}
+}
+
+func TestLongLines(t *testing.T) {
+	long := strings.Repeat("QUJD", 50<<10) // 200KB, past bufio.MaxScanTokenSize
+	input := "[s]\nbefore=1\nblob=" + long + "\nafter=2\n"
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr error
+	}{
+		{"default limit", nil, nil},
+		{"raised limit", []Option{WithMaxLineLength(1 << 21)}, nil},
+		{"lowered limit", []Option{WithMaxLineLength(1 << 16)}, ErrLineTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.opts...)
+			err := p.LoadFromReader(strings.NewReader(input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromReader() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var perr *ParseError
+				if !errors.As(err, &perr) || perr.Line != 3 {
+					t.Errorf("error = %v, want a *ParseError on line 3", err)
+				}
+				return
+			}
+			if got, _ := p.Get("s", "blob"); got != long {
+				t.Errorf("Get(s, blob) has length %d, want %d", len(got), len(long))
+			}
+			if got, _ := p.Get("s", "after"); got != "2" {
+				t.Errorf("Get(s, after) = %q, want 2", got)
+			}
+		})
+	}
+}
+
+func TestLongLineFromFile(t *testing.T) {
+	long := strings.Repeat("x", 200<<10)
+	p := NewParser()
+	if err := p.LoadFromFile(writeFiles(t, "[s]\nblob="+long+"\n")[0]); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if got, _ := p.Get("s", "blob"); len(got) != len(long) {
+		t.Errorf("Get(s, blob) has length %d, want %d", len(got), len(long))
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		files   []string
+		wantErr error
+		want    map[string]map[string]string
+	}{
+		{
+			name:  "child sections appear",
+			opts:  []Option{WithIncludes()},
+			files: []string{"[app]\nname=demo\ninclude=1.ini\n", "[database]\nport=5432\n"},
+			want:  map[string]map[string]string{"app": {"name": "demo"}, "database": {"port": "5432"}},
+		},
+		{
+			name:  "child starts in the including section",
+			opts:  []Option{WithIncludes()},
+			files: []string{"[app]\ninclude = 1.ini\nafter=1\n", "extra=x\n"},
+			want:  map[string]map[string]string{"app": {"extra": "x", "after": "1"}},
+		},
+		{
+			name:  "nested includes",
+			opts:  []Option{WithIncludes()},
+			files: []string{"include=1.ini\n", "include=2.ini\n", "[deep]\nk=v\n"},
+			want:  map[string]map[string]string{"deep": {"k": "v"}},
+		},
+		{
+			name:  "custom key",
+			opts:  []Option{func(p *Parser) { p.SetIncludeKey("@import") }},
+			files: []string{"@import=1.ini\ninclude=kept\n", "[s]\nk=v\n"},
+			want:  map[string]map[string]string{"": {"include": "kept"}, "s": {"k": "v"}},
+		},
+		{
+			name:  "disabled by default",
+			files: []string{"[s]\ninclude=1.ini\n", "[t]\nk=v\n"},
+			want:  map[string]map[string]string{"s": {"include": "1.ini"}},
+		},
+		{
+			name:    "self include",
+			opts:    []Option{WithIncludes()},
+			files:   []string{"[s]\ninclude=0.ini\n"},
+			wantErr: ErrIncludeCycle,
+		},
+		{
+			name:    "indirect cycle",
+			opts:    []Option{WithIncludes()},
+			files:   []string{"include=1.ini\n", "include=0.ini\n"},
+			wantErr: ErrIncludeCycle,
+		},
+		{
+			name:    "missing file",
+			opts:    []Option{WithIncludes()},
+			files:   []string{"include=missing.ini\n"},
+			wantErr: fs.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.opts...)
+			err := p.LoadFromFile(writeFiles(t, tt.files...)[0])
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromFile() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := p.GetSections()
+			if len(got) != len(tt.want) {
+				t.Fatalf("sections = %v, want %v", got, tt.want)
+			}
+			for name, keys := range tt.want {
+				if !maps.Equal(got[name], keys) {
+					t.Errorf("section %q = %v, want %v", name, got[name], keys)
+				}
+			}
+		})
+	}
+}
+
+func TestSectionNameTrimming(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    string
+		wantErr error
+	}{
+		{"[ owner ]", "owner", nil},
+		{"[my db]", "my db", nil},
+		{"[ my  db ]", "my  db", nil},
+		{"[\tdb\t]", "db", nil},
+		{"[  ]", "", ErrSectionIsEmpty},
+		{"[]", "", ErrSectionIsEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			p, err := Parse(tt.header + "\nk=v\n")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got, err := p.Get(tt.want, "k"); err != nil || got != "v" {
+				t.Errorf("Get(%q, k) = %q, %v, want v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestQuotedValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"leading hash", `prefix="#tag"`, "#tag"},
+		{"leading semicolon", `prefix=";tag" ; comment`, ";tag"},
+		{"semicolon inside", `prefix="a ; b"`, "a ; b"},
+		{"hash inside with trailing comment", `prefix="a # b" # comment`, "a # b"},
+		{"padded", `prefix="  padded  "`, "  padded  "},
+		{"empty quotes", `prefix=""`, ""},
+		{"unquoted comment stripped", `prefix=a ; b`, "a"},
+		{"unclosed quote keeps the rest", `prefix="a ; b`, `"a ; b`},
+		{"inner quotes kept", `prefix=say "hi"`, `say "hi"`},
+		{"escaped quote", `prefix="a\" ; b" ; comment`, `a" ; b`},
+		{"escaped backslash", `prefix="C:\\dir\\" ; comment`, `C:\dir\`},
+		{"other backslashes kept", `prefix=" C:\dir"`, ` C:\dir`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\n"+tt.input+"\n", withInlineComments(), WithAllowEmptyValues())
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, _ := p.Get("s", "prefix")
+			if got != tt.want {
+				t.Fatalf("Get() = %q, want %q", got, tt.want)
+			}
+			reloaded, err := Parse(p.String(), withInlineComments(), WithAllowEmptyValues())
+			if err != nil {
+				t.Fatalf("reloading %q: %v", p.String(), err)
+			}
+			if got, _ := reloaded.Get("s", "prefix"); got != tt.want {
+				t.Errorf("Get() after reloading %q = %q, want %q", p.String(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotedValuesSurviveSet(t *testing.T) {
+	values := []string{
+		`a" ;b "c ; d`,
+		`"quoted"`,
+		` trailing backslash\ `,
+		`\" ; x`,
+		`say "hi" # there`,
+	}
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			p := NewParser(withInlineComments())
+			if err := p.Set("x", "k", value); err != nil {
+				t.Fatal(err)
+			}
+			reloaded, err := Parse(p.String(), withInlineComments())
+			if err != nil {
+				t.Fatalf("reloading %q: %v", p.String(), err)
+			}
+			if got, _ := reloaded.Get("x", "k"); got != value {
+				t.Errorf("Get() after reloading %q = %q, want %q", p.String(), got, value)
+			}
+		})
+	}
+}
+
+func TestValidateUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantErr  error
+		wantLine int
+		wantCol  int
+	}{
+		{"valid", "[s]\nname=Jürgen\n", nil, 0, 0},
+		{"latin-1 value", "[s]\nname=J\xfcrgen\n", ErrInvalidUTF8, 2, 7},
+		{"binary key", "\xff\xfe=1\n[s]\n", ErrInvalidUTF8, 1, 1},
+		{"truncated sequence", "[s]\nk=v\nx=\xe2\x82\n", ErrInvalidUTF8, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewParser().LoadFromString(tt.input); err != nil {
+				t.Fatalf("without validation, LoadFromString() error = %v", err)
+			}
+			err := NewParser(WithValidateUTF8()).LoadFromString(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromString() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Line != tt.wantLine || perr.Column != tt.wantCol {
+				t.Errorf("error = %v, want line %d:%d", err, tt.wantLine, tt.wantCol)
+			}
+		})
+	}
+}
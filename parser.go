@@ -0,0 +1,839 @@
+// Package iniparser reads, edits and writes INI configuration files.
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Parser holds the sections and key/value pairs of a parsed INI document.
+// It is safe for concurrent use.
+type Parser struct {
+	mu sync.RWMutex
+	document
+
+	settings
+}
+
+// document is the content of a parser: its entries and the comments
+// attached to them.
+type document struct {
+	parsedData map[string]map[string]string
+
+	// comments holds comment lines indexed by section and then by the key
+	// they precede; comments above a section header are stored under the
+	// key "". trailingComments follow the last entry.
+	comments         map[string]map[string][]string
+	trailingComments []string
+
+	// values holds every value of keys repeated in multi-value mode,
+	// indexed like comments; parsedData holds the last of them.
+	values map[string]map[string][]string
+
+	// raw holds the text loaded keys were read from, before quotes and
+	// inline comments were removed, where it differs from the value.
+	raw map[string]map[string]string
+
+	// path is the file the content was loaded from, if any.
+	path string
+
+	// dirty holds the sections changed since the content was loaded or
+	// saved to a file.
+	dirty map[string]bool
+}
+
+// NewParser returns an empty Parser configured by opts. Without options it
+// uses the defaults documented on each setting.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		document: document{parsedData: make(map[string]map[string]string)},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// NewParserWithOptions is NewParser spelled out for call sites that want
+// the options to stand out.
+func NewParserWithOptions(opts ...Option) *Parser {
+	return NewParser(opts...)
+}
+
+// Parse returns a new parser configured by opts and loaded from data with
+// LoadFromString.
+func Parse(data string, opts ...Option) (*Parser, error) {
+	p := NewParser(opts...)
+	if err := p.LoadFromString(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParseFile returns a new parser configured by opts and loaded from the file
+// at path with LoadFromFile.
+func ParseFile(path string, opts ...Option) (*Parser, error) {
+	p := NewParser(opts...)
+	if err := p.LoadFromFile(path); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// emptyCopy returns a parser with no content and the same settings as p.
+// It must be called with p.mu held.
+func (p *Parser) emptyCopy() *Parser {
+	cp := NewParser()
+	cp.settings = p.settings
+	return cp
+}
+
+// LoadFromString parses data and replaces the parser's content with it.
+// Keys that appear before the first section header are stored under the
+// empty section name "". Section names are trimmed of surrounding
+// whitespace but keep their inner spacing, so "[ my db ]" names the section
+// "my db", and inside a header `\]` stands for a literal "]" when another
+// "]" follows it. A leading UTF-8 byte-order mark is ignored. Empty or blank
+// input leaves the parser empty without error, unless strict mode is
+// enabled.
+//
+// A key ends at the first separator and its value runs to the end of the
+// line, so a value such as "user:pass@tcp(host:3306)/db?tls=true#frag" is
+// kept verbatim, "=" and "#" included. With inline comments enabled, a
+// comment character preceded by whitespace ends the value unless the value
+// is quoted. A value wrapped in double quotes is stored without them, which
+// keeps surrounding spaces and comment characters inside it; String quotes
+// such values again.
+func (p *Parser) LoadFromString(data string) error {
+	p.mu.RLock()
+	parsed, err := p.parseLines(strings.Split(data, "\n"))
+	p.mu.RUnlock()
+	p.replaceData(parsed)
+	return err
+}
+
+// LoadFromStringWithWarnings is a best-effort LoadFromString. Lines with an
+// empty key or value, lines rejected by strict mode and lines that are not
+// key/value pairs are skipped, and a duplicate key keeps the value chosen
+// by the duplicate strategy, the first one under DuplicateError; each such
+// case is reported as a Warning. Problems that make the rest of
+// the input unreliable, such as a malformed section header, are still
+// returned as an error, in which case the parser's content is unchanged.
+func (p *Parser) LoadFromStringWithWarnings(data string) ([]Warning, error) {
+	p.mu.RLock()
+	lp := p.newLineParser()
+	p.mu.RUnlock()
+
+	lp.collectWarnings = true
+	for _, line := range strings.Split(data, "\n") {
+		if err := lp.parseLine(line); err != nil {
+			return lp.warnings, err
+		}
+	}
+	parsed, err := lp.result()
+	p.replaceData(parsed)
+	return lp.warnings, err
+}
+
+// LoadFromFile reads the file at path and parses its content. When include
+// directives are enabled with WithIncludes or SetIncludeKey, the files they
+// name are loaded as well.
+func (p *Parser) LoadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	parsed, err := p.parseReader(f, path)
+	if parsed != nil {
+		parsed.path = path
+	}
+	p.replaceData(parsed)
+	return err
+}
+
+// LoadFiles parses the files at paths in order and replaces the current
+// content with the result, for layered configs such as defaults followed by
+// host-specific overrides. A key set in a later file replaces the same key
+// from an earlier one, all of its values and comments included, whatever
+// the duplicate strategy or multi-value setting; those only apply to keys
+// repeated within one file. All other keys are kept. Parse errors are
+// prefixed with the path of the file that caused them. FilePath reports ""
+// afterwards, since the content no longer comes from a single file.
+func (p *Parser) LoadFiles(paths ...string) error {
+	parsed, err := p.parseFiles(paths, false)
+	p.replaceData(parsed)
+	return err
+}
+
+// LoadFilesOptional is like LoadFiles but skips files that do not exist.
+func (p *Parser) LoadFilesOptional(paths ...string) error {
+	parsed, err := p.parseFiles(paths, true)
+	p.replaceData(parsed)
+	return err
+}
+
+// FilePath returns the path the current content was loaded from with
+// LoadFromFile, or "" if it came from anywhere else.
+func (p *Parser) FilePath() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.path
+}
+
+// Reload discards the current content, unsaved edits included, and loads
+// the file it came from again. It returns ErrNoFileLoaded if the content
+// was not loaded with LoadFromFile.
+func (p *Parser) Reload() error {
+	path := p.FilePath()
+	if path == "" {
+		return ErrNoFileLoaded
+	}
+	return p.LoadFromFile(path)
+}
+
+// LoadFromFS reads the file name from fsys and parses its content. It lets
+// a default config embedded with embed.FS be loaded the same way as one on
+// disk.
+func (p *Parser) LoadFromFS(fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return p.LoadFromReader(f)
+}
+
+// LoadFromReader parses the content read from r. The input is consumed one
+// line at a time, so large files are never held in memory as a whole.
+func (p *Parser) LoadFromReader(r io.Reader) error {
+	parsed, err := p.parseReader(r, "")
+	p.replaceData(parsed)
+	return err
+}
+
+// replaceData swaps in a freshly parsed document. A nil document means
+// parsing failed outright and the current content is kept.
+func (p *Parser) replaceData(parsed *document) {
+	if parsed == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.document = *parsed
+}
+
+// ReadFrom parses the content read from r and returns the number of bytes
+// read. It implements io.ReaderFrom. Parser is deliberately not an
+// io.Writer, since a document cannot be parsed one chunk at a time, so call
+// ReadFrom directly instead of io.Copy.
+func (p *Parser) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	err := p.LoadFromReader(cr)
+	return cr.n, err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += int64(n)
+	return n, err
+}
+
+// GetSectionNames returns the names of all sections in lexicographic order,
+// so listings are stable from one call to the next.
+func (p *Parser) GetSectionNames() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return sortedKeys(p.parsedData)
+}
+
+// GetSortedSectionNames returns the names of all sections in lexicographic
+// order.
+//
+// Deprecated: GetSectionNames is sorted as well; use it instead.
+func (p *Parser) GetSortedSectionNames() []string {
+	return p.GetSectionNames()
+}
+
+// GetSections returns a copy of all sections and their key/value pairs.
+func (p *Parser) GetSections() map[string]map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sections := make(map[string]map[string]string, len(p.parsedData))
+	for name, keys := range p.parsedData {
+		sections[name] = copySection(keys)
+	}
+	return sections
+}
+
+// Get returns the value of key in section.
+func (p *Parser) Get(section, key string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	section, key, err := p.lookup(section, key)
+	if err != nil {
+		return "", err
+	}
+	return p.parsedData[section][key], nil
+}
+
+// lookup returns the stored names of section and key, falling back to the
+// default section set with SetDefaultSection for a key missing from
+// section. It must be called with p.mu held.
+func (p *Parser) lookup(section, key string) (string, string, error) {
+	section, ok := lookupName(p.parsedData, section, p.caseInsensitive)
+	if !ok {
+		return "", "", ErrSectionNotFound
+	}
+	if name, ok := lookupName(p.parsedData[section], key, p.caseInsensitive); ok {
+		return section, name, nil
+	}
+	defaults, ok := lookupName(p.parsedData, p.defaultSection, p.caseInsensitive)
+	if p.defaultSection == "" || !ok || defaults == section {
+		return "", "", ErrKeyNotFound
+	}
+	if name, ok := lookupName(p.parsedData[defaults], key, p.caseInsensitive); ok {
+		return defaults, name, nil
+	}
+	return "", "", ErrKeyNotFound
+}
+
+// Set stores value under key in section, creating the section and the key
+// if they do not exist yet. An empty value is rejected with ErrValueIsEmpty
+// unless AllowEmptyValues is enabled, and line breaks in any argument with
+// ErrLineBreak. Spaces around section are trimmed, as they are in a section
+// header. Use UpdateKey to write only to keys that already exist.
+func (p *Parser) Set(section, key, value string) error {
+	section = strings.TrimSpace(section)
+	if section == "" {
+		return ErrSectionIsEmpty
+	}
+	if key == "" {
+		return ErrKeyIsEmpty
+	}
+	if err := checkLineBreaks(section, key, value); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if value == "" && !p.allowEmptyValues {
+		return ErrValueIsEmpty
+	}
+
+	keys := p.ensureSection(section)
+	key, _ = lookupName(keys, key, p.caseInsensitive)
+	keys[key] = value
+	p.dropValues(section, key)
+	p.dropRaw(section, key)
+	p.markDirty(section)
+	return nil
+}
+
+// UpdateKey changes the value of an existing key. Unlike Set it never
+// creates anything: it returns ErrSectionNotFound or ErrKeyNotFound instead.
+// The value is checked as by Set.
+func (p *Parser) UpdateKey(section, key, value string) error {
+	if err := checkLineBreaks(value); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if value == "" && !p.allowEmptyValues {
+		return ErrValueIsEmpty
+	}
+
+	keys, ok := p.section(section)
+	if !ok {
+		return ErrSectionNotFound
+	}
+	key, ok = lookupName(keys, key, p.caseInsensitive)
+	if !ok {
+		return ErrKeyNotFound
+	}
+	keys[key] = value
+	p.dropValues(section, key)
+	p.dropRaw(section, key)
+	p.markDirty(section)
+	return nil
+}
+
+// AddKey creates key in section, creating the section if needed. It returns
+// ErrKeyAlreadyExists instead of overwriting an existing key. The arguments
+// are checked and the section name trimmed as by Set.
+func (p *Parser) AddKey(section, key, value string) error {
+	section = strings.TrimSpace(section)
+	if section == "" {
+		return ErrSectionIsEmpty
+	}
+	if key == "" {
+		return ErrKeyIsEmpty
+	}
+	if err := checkLineBreaks(section, key, value); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if value == "" && !p.allowEmptyValues {
+		return ErrValueIsEmpty
+	}
+
+	keys := p.ensureSection(section)
+	if _, ok := lookupName(keys, key, p.caseInsensitive); ok {
+		return ErrKeyAlreadyExists
+	}
+	keys[key] = value
+	p.markDirty(section)
+	return nil
+}
+
+// SetIfAbsent stores value under key in section only if the key does not
+// exist yet, creating the section if needed. It reports whether the value was
+// written, which makes it suitable for seeding defaults without clobbering
+// loaded settings.
+func (p *Parser) SetIfAbsent(section, key, value string) (bool, error) {
+	err := p.AddKey(section, key, value)
+	if errors.Is(err, ErrKeyAlreadyExists) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RenameKey moves the value of oldKey in section to newKey. It returns
+// ErrKeyNotFound if oldKey is missing, ErrKeyAlreadyExists if newKey is
+// already present and ErrLineBreak if newKey contains a line break.
+func (p *Parser) RenameKey(section, oldKey, newKey string) error {
+	if newKey == "" {
+		return ErrKeyIsEmpty
+	}
+	if err := checkLineBreaks(newKey); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys, ok := p.section(section)
+	if !ok {
+		return ErrSectionNotFound
+	}
+	oldKey, ok = lookupName(keys, oldKey, p.caseInsensitive)
+	if !ok {
+		return ErrKeyNotFound
+	}
+	// In case-insensitive mode newKey may be a respelling of oldKey itself.
+	if existing, ok := lookupName(keys, newKey, p.caseInsensitive); ok && existing != oldKey {
+		return ErrKeyAlreadyExists
+	}
+	value := keys[oldKey]
+	delete(keys, oldKey)
+	keys[newKey] = value
+	p.moveComments(section, oldKey, newKey)
+	p.moveValues(section, oldKey, newKey)
+	p.moveRaw(section, oldKey, newKey)
+	p.markDirty(section)
+	return nil
+}
+
+// DeleteKey removes key from section.
+func (p *Parser) DeleteKey(section, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys, ok := p.section(section)
+	if !ok {
+		return ErrSectionNotFound
+	}
+	key, ok = lookupName(keys, key, p.caseInsensitive)
+	if !ok {
+		return ErrKeyNotFound
+	}
+	delete(keys, key)
+	p.dropComments(section, key)
+	p.dropValues(section, key)
+	p.dropRaw(section, key)
+	p.markDirty(section)
+	return nil
+}
+
+// DeleteSection removes section and all of its keys.
+func (p *Parser) DeleteSection(section string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	section, ok := lookupName(p.parsedData, section, p.caseInsensitive)
+	if !ok {
+		return ErrSectionNotFound
+	}
+	p.markDirty(section)
+	delete(p.parsedData, section)
+	delete(p.comments, section)
+	delete(p.values, section)
+	delete(p.raw, section)
+	return nil
+}
+
+// String serializes the parser's content in INI format. Sections and keys
+// are written in lexicographic order so the output is deterministic; keys of
+// the unnamed section come first, before any header. A section without keys
+// is written as a bare header, so it survives a reload. Preserved comments
+// are written above the header or key they were attached to.
+func (p *Parser) String() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var sb strings.Builder
+	p.write(&sb, p.delimiter(), false)
+	return sb.String()
+}
+
+// StringAligned is like String but pads the keys of each section to the
+// width of its longest key, so the values line up:
+//
+//	name    = John
+//	country = Egypt
+//
+// It uses the delimiter set by SetOutputDelimiter, or else the separator
+// surrounded by spaces. The padding is trimmed again on load.
+func (p *Parser) StringAligned() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	delim := p.outputDelimiter
+	if delim == "" {
+		delim = " " + p.seps()[:1] + " "
+	}
+	var sb strings.Builder
+	p.write(&sb, delim, true)
+	return sb.String()
+}
+
+// Compact returns a single-line summary of the content for log messages,
+// such as `database{port=5432} owner{name=John Doe}`. Sections and keys are
+// sorted and line breaks in values are escaped. Unlike String, the output
+// is not meant to be parsed back.
+func (p *Parser) Compact() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sections := make([]string, 0, len(p.parsedData))
+	for _, name := range sortedKeys(p.parsedData) {
+		keys := p.parsedData[name]
+		pairs := make([]string, 0, len(keys))
+		for _, key := range sortedKeys(keys) {
+			pairs = append(pairs, key+"="+compactEscaper.Replace(keys[key]))
+		}
+		sections = append(sections, name+"{"+strings.Join(pairs, ";")+"}")
+	}
+	return strings.Join(sections, " ")
+}
+
+var compactEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+
+// write serializes the content to w with delim between keys and values,
+// padding keys per section when align is set, and returns the number of
+// bytes written. Output stops at the first write error. It must be called
+// with p.mu held.
+func (p *Parser) write(w io.Writer, delim string, align bool) (int64, error) {
+	cw := &countingWriter{w: w}
+	var out io.Writer = cw
+	if p.lineEnding != "" && p.lineEnding != "\n" {
+		out = &lineEndingWriter{w: cw, eol: p.lineEnding}
+	}
+	for i, name := range sortedKeys(p.parsedData) {
+		if i > 0 {
+			io.WriteString(out, "\n")
+		}
+		writeComments(out, p.comments[name][""])
+		if name != "" {
+			io.WriteString(out, formatSection(name)+"\n")
+		}
+		keys := p.parsedData[name]
+		names := sortedKeys(keys)
+		formatted := make([]string, len(names))
+		width := 0
+		for j, key := range names {
+			formatted[j] = p.formatKey(key)
+			width = max(width, utf8.RuneCountInString(formatted[j]))
+		}
+		for j, key := range names {
+			pad := 0
+			if align {
+				pad = width - utf8.RuneCountInString(formatted[j])
+			}
+			writeComments(out, p.comments[name][key])
+			values, ok := p.values[name][key]
+			if !ok {
+				values = []string{keys[key]}
+			}
+			for _, value := range values {
+				fmt.Fprintf(out, "%s%s%s%s\n", formatted[j], strings.Repeat(" ", pad), delim, p.formatValue(value))
+			}
+		}
+	}
+	if len(p.trailingComments) > 0 && len(p.parsedData) > 0 {
+		io.WriteString(out, "\n")
+	}
+	writeComments(out, p.trailingComments)
+	return cw.n, cw.err
+}
+
+// SaveToFile writes the serialized content to path. An existing file keeps
+// its mode; a new one is created with mode 0644, less the umask. See
+// SaveToFileMode for how the file is replaced.
+func (p *Parser) SaveToFile(path string) error {
+	return p.saveToFile(path, 0644, true)
+}
+
+// SaveToFileMode is like SaveToFile but gives the file permissions perm,
+// e.g. 0600 for configs holding secrets. An existing file is changed to
+// perm; a new one is created with perm less the umask. The content is
+// written to a temporary file in the same directory which is then renamed
+// over path, so a crash mid-write never leaves a truncated config behind.
+// On error the temporary file is removed and the original file is left
+// untouched. A successful save clears the dirty state reported by IsDirty.
+func (p *Parser) SaveToFileMode(path string, perm os.FileMode) error {
+	return p.saveToFile(path, perm, false)
+}
+
+// saveToFile implements SaveToFile and SaveToFileMode. When path exists,
+// the file ends up with its current mode if keepMode is set, or else with
+// perm.
+func (p *Parser) saveToFile(path string, perm os.FileMode, keepMode bool) (err error) {
+	// The write lock keeps changes from landing between writing the file
+	// and clearing the dirty state.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if info != nil && keepMode {
+		perm = info.Mode().Perm()
+	}
+	tmp, err := createTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp", perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// Replacing an existing file sets its mode exactly, without the umask
+	// applied on creation.
+	if info != nil {
+		if err = tmp.Chmod(perm); err != nil {
+			return err
+		}
+	}
+	if _, err = p.write(tmp, p.delimiter(), false); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	p.dirty = nil
+	return nil
+}
+
+// createTemp creates a new file in dir whose name is prefix followed by a
+// random number. Unlike os.CreateTemp, which always uses mode 0600, it
+// creates the file with perm less the umask.
+func createTemp(dir, prefix string, perm os.FileMode) (*os.File, error) {
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) && try < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
+
+// SaveTo writes the same content SaveToFile would to w, such as os.Stdout
+// or a network connection.
+func (p *Parser) SaveTo(w io.Writer) error {
+	_, err := p.WriteTo(w)
+	return err
+}
+
+// WriteTo writes the serialized content, comments included, to w and
+// returns the number of bytes written. It implements io.WriterTo. The
+// output is streamed section by section rather than built in memory first;
+// the parser is read-locked until it is done.
+func (p *Parser) WriteTo(w io.Writer) (int64, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.write(w, p.delimiter(), false)
+}
+
+// lineEndingWriter replaces each "\n" written through it with eol.
+type lineEndingWriter struct {
+	w   io.Writer
+	eol string
+}
+
+func (lw *lineEndingWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(lw.w, strings.ReplaceAll(string(b), "\n", lw.eol)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// countingWriter counts the bytes written through it and remembers the
+// first error, after which it writes nothing more.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// checkLineBreaks returns an error wrapping ErrLineBreak for the first of
+// fields that contains a line break.
+func checkLineBreaks(fields ...string) error {
+	for _, field := range fields {
+		if strings.ContainsAny(field, "\r\n") {
+			return fmt.Errorf("%w: %q", ErrLineBreak, field)
+		}
+	}
+	return nil
+}
+
+// ensureSection returns the keys of section, creating the section (and the
+// underlying map, so a zero Parser is usable) when missing. It must be called
+// with p.mu held for writing.
+func (p *Parser) ensureSection(section string) map[string]string {
+	if p.parsedData == nil {
+		p.parsedData = make(map[string]map[string]string)
+	}
+	section, ok := lookupName(p.parsedData, section, p.caseInsensitive)
+	if !ok {
+		p.parsedData[section] = make(map[string]string)
+	}
+	return p.parsedData[section]
+}
+
+// section returns the keys of the named section, honoring case-insensitive
+// mode. It must be called with p.mu held.
+func (p *Parser) section(name string) (map[string]string, bool) {
+	name, ok := lookupName(p.parsedData, name, p.caseInsensitive)
+	return p.parsedData[name], ok
+}
+
+// lookupName returns the key of m that name refers to. An exact match always
+// wins; when fold is set, any spelling equal under case folding also matches.
+// If nothing matches, name itself is returned with false.
+func lookupName[V any](m map[string]V, name string, fold bool) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	if fold {
+		for k := range m {
+			if strings.EqualFold(k, name) {
+				return k, true
+			}
+		}
+	}
+	return name, false
+}
+
+func copySection(keys map[string]string) map[string]string {
+	cp := make(map[string]string, len(keys))
+	for k, v := range keys {
+		cp[k] = v
+	}
+	return cp
+}
+
+// copySectionFrom copies section name of src into d, with the comments,
+// multiple values and raw text that belong to it.
+func (d *document) copySectionFrom(src *document, name string) {
+	d.parsedData[name] = copySection(src.parsedData[name])
+	if comments, ok := src.comments[name]; ok {
+		if d.comments == nil {
+			d.comments = make(map[string]map[string][]string)
+		}
+		d.comments[name] = copyLists(comments)
+	}
+	if values, ok := src.values[name]; ok {
+		if d.values == nil {
+			d.values = make(map[string]map[string][]string)
+		}
+		d.values[name] = copyLists(values)
+	}
+	if raw, ok := src.raw[name]; ok {
+		if d.raw == nil {
+			d.raw = make(map[string]map[string]string)
+		}
+		d.raw[name] = copySection(raw)
+	}
+}
+
+func copyLists(lists map[string][]string) map[string][]string {
+	cp := make(map[string][]string, len(lists))
+	for k, v := range lists {
+		cp[k] = slices.Clone(v)
+	}
+	return cp
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
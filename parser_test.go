@@ -0,0 +1,1182 @@
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFromStringMalformedSectionHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing closing bracket", "[database\nport=5432"},
+		{"missing opening bracket", "database]\nport=5432"},
+		{"header holding separator", "[database=x\nport=5432"},
+		{"text after closing bracket", "[database] extra\nport=5432"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			err := p.LoadFromString(tt.input)
+			if !errors.Is(err, ErrMalformedSectionHeader) {
+				t.Fatalf("LoadFromString(%q) error = %v, want %v", tt.input, err, ErrMalformedSectionHeader)
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Line != 1 {
+				t.Fatalf("LoadFromString(%q) error = %v, want a ParseError on line 1", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestLoadFromStringValidSectionHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		section string
+	}{
+		{"plain", "[database]\nport=5432", "database"},
+		{"value ending in bracket", "[database]\npattern=[a-z]", "database"},
+		{"trailing comment", "[database] ; primary\nport=5432", "database"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			if err := p.LoadFromString(tt.input); err != nil {
+				t.Fatalf("LoadFromString(%q) error = %v", tt.input, err)
+			}
+			if _, err := p.Section(tt.section); err != nil {
+				t.Fatalf("Section(%q) error = %v", tt.section, err)
+			}
+		})
+	}
+}
+
+// createdMode returns the mode a new file created with perm gets, which
+// depends on the umask of the process running the tests.
+func createdMode(t *testing.T, perm os.FileMode) os.FileMode {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "probe")
+	if err := os.WriteFile(path, nil, perm); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Mode().Perm()
+}
+
+func TestSaveToFileMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		save     func(p *Parser, path string) error
+		existing os.FileMode // 0 means path does not exist yet
+		want     os.FileMode
+	}{
+		{"SaveToFileMode new file", saveMode(0600), 0, createdMode(t, 0600)},
+		{"SaveToFileMode tightens existing file", saveMode(0600), 0644, 0600},
+		{"SaveToFileMode loosens existing file", saveMode(0644), 0600, 0644},
+		{"SaveToFile new file", (*Parser).SaveToFile, 0, createdMode(t, 0644)},
+		{"SaveToFile keeps secrets file mode", (*Parser).SaveToFile, 0600, 0600},
+		{"SaveToFile keeps unusual mode", (*Parser).SaveToFile, 0640, 0640},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.ini")
+			if tt.existing != 0 {
+				if err := os.WriteFile(path, []byte("[old]\nk=v\n"), tt.existing); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chmod(path, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, err := Parse("[owner]\nname=John\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.save(p, path); err != nil {
+				t.Fatalf("save error = %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tt.want {
+				t.Errorf("mode = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func saveMode(perm os.FileMode) func(p *Parser, path string) error {
+	return func(p *Parser, path string) error {
+		return p.SaveToFileMode(path, perm)
+	}
+}
+
+func TestSaveToFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.ini")
+	if err := os.WriteFile(path, []byte("[old]\nk=v\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Parse("[owner]\nname=John\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	got, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(p) {
+		t.Errorf("saved content = %q, want %q", got.String(), p.String())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries after save, want only the config", len(entries))
+	}
+
+	if err := p.SaveToFile(filepath.Join(dir, "missing", "config.ini")); err == nil {
+		t.Error("SaveToFile() into a missing directory succeeded")
+	}
+}
+
+// writeFiles writes each of contents to its own file in a temporary
+// directory and returns their paths in order.
+func writeFiles(t *testing.T, contents ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, len(contents))
+	for i, content := range contents {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d.ini", i))
+		if err := os.WriteFile(paths[i], []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestLoadFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		files []string
+		key   string
+		want  []string
+	}{
+		{
+			name:  "later file overrides",
+			files: []string{"[s]\nk=1\nother=x\n", "[s]\nk=2\n"},
+			key:   "k",
+			want:  []string{"2"},
+		},
+		{
+			name:  "keys only in earlier file are kept",
+			files: []string{"[s]\nk=1\nother=x\n", "[s]\nk=2\n"},
+			key:   "other",
+			want:  []string{"x"},
+		},
+		{
+			name:  "case-insensitive respelling overrides",
+			opts:  []Option{WithCaseInsensitive()},
+			files: []string{"[s]\nName=1\n", "[S]\nname=2\n"},
+			key:   "NAME",
+			want:  []string{"2"},
+		},
+		{
+			name:  "multi-value key is replaced, not appended",
+			opts:  []Option{WithMultiValue()},
+			files: []string{"[s]\nk=1\nk=2\n", "[s]\nk=3\n"},
+			key:   "k",
+			want:  []string{"3"},
+		},
+		{
+			name:  "multi-value key repeated within a file",
+			opts:  []Option{WithMultiValue()},
+			files: []string{"[s]\nk=1\n", "[s]\nk=2\nk=3\n"},
+			key:   "k",
+			want:  []string{"2", "3"},
+		},
+		{
+			name:  "DuplicateError allows overrides",
+			opts:  []Option{WithDuplicateStrategy(DuplicateError)},
+			files: []string{"[s]\nk=1\n", "[s]\nk=2\n"},
+			key:   "k",
+			want:  []string{"2"},
+		},
+		{
+			name:  "DuplicateFirst still lets the later file win",
+			opts:  []Option{WithDuplicateStrategy(DuplicateFirst)},
+			files: []string{"[s]\nk=1\n", "[s]\nk=2\nk=3\n"},
+			key:   "k",
+			want:  []string{"2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.opts...)
+			if err := p.LoadFiles(writeFiles(t, tt.files...)...); err != nil {
+				t.Fatalf("LoadFiles() error = %v", err)
+			}
+			got, err := p.GetValues("s", tt.key)
+			if err != nil {
+				t.Fatalf("GetValues(%q) error = %v", tt.key, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetValues(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFilesErrors(t *testing.T) {
+	t.Run("parse error names the file", func(t *testing.T) {
+		paths := writeFiles(t, "[s]\nk=1\n", "[s\n")
+		err := NewParser().LoadFiles(paths...)
+		if !errors.Is(err, ErrMalformedSectionHeader) || !strings.Contains(err.Error(), paths[1]) {
+			t.Errorf("LoadFiles() error = %v, want %v naming %s", err, ErrMalformedSectionHeader, paths[1])
+		}
+	})
+	t.Run("duplicate within one file", func(t *testing.T) {
+		paths := writeFiles(t, "[s]\nk=1\n", "[s]\nk=2\nk=3\n")
+		err := NewParser(WithDuplicateStrategy(DuplicateError)).LoadFiles(paths...)
+		if !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("LoadFiles() error = %v, want %v", err, ErrDuplicateKey)
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		paths := writeFiles(t, "[s]\nk=1\n")
+		missing := filepath.Join(filepath.Dir(paths[0]), "missing.ini")
+		p := NewParser()
+		if err := p.LoadFiles(paths[0], missing); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("LoadFiles() error = %v, want %v", err, fs.ErrNotExist)
+		}
+		if err := p.LoadFilesOptional(paths[0], missing); err != nil {
+			t.Fatalf("LoadFilesOptional() error = %v", err)
+		}
+		if got, _ := p.Get("s", "k"); got != "1" {
+			t.Errorf("Get() = %q, want %q", got, "1")
+		}
+	})
+}
+
+func TestSettersRejectLineBreaks(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(p *Parser) error
+	}{
+		{"Set value", func(p *Parser) error { return p.Set("s", "k", "a\n[evil]\nx=1") }},
+		{"Set key", func(p *Parser) error { return p.Set("s", "k\nx", "v") }},
+		{"Set section", func(p *Parser) error { return p.Set("s]\n[evil", "k", "v") }},
+		{"Set carriage return", func(p *Parser) error { return p.Set("s", "k", "a\rb") }},
+		{"UpdateKey", func(p *Parser) error { return p.UpdateKey("s", "k", "a\nb") }},
+		{"AddKey", func(p *Parser) error { return p.AddKey("s", "new", "a\nb") }},
+		{"RenameKey", func(p *Parser) error { return p.RenameKey("s", "k", "a\nb") }},
+		{"SetSection value", func(p *Parser) error { return p.SetSection("s", map[string]string{"k": "a\nb"}) }},
+		{"SetSection name", func(p *Parser) error { return p.SetSection("a\nb", map[string]string{"k": "v"}) }},
+		{"GetOrCreateSection", func(p *Parser) error { _, err := p.GetOrCreateSection("a\nb"); return err }},
+		{"LoadFromFlatMap", func(p *Parser) error { return p.LoadFromFlatMap(map[string]string{"s.k": "a\nb"}, ".") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\nk=v\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.call(p); !errors.Is(err, ErrLineBreak) {
+				t.Fatalf("error = %v, want %v", err, ErrLineBreak)
+			}
+			if got := p.String(); got != "[s]\nk=v\n" {
+				t.Errorf("content changed to %q", got)
+			}
+		})
+	}
+}
+
+func TestSetSurvivesReload(t *testing.T) {
+	p := NewParser()
+	p.AllowEmptyValues(true)
+	values := map[string]string{
+		"empty":   "",
+		"spaces":  "  padded  ",
+		"quoted":  `"already quoted"`,
+		"comment": "a ; b # c",
+		"equals":  "a=b=c",
+	}
+	for key, value := range values {
+		if err := p.Set("s", key, value); err != nil {
+			t.Fatalf("Set(%q, %q) error = %v", key, value, err)
+		}
+	}
+	reloaded := NewParser()
+	reloaded.AllowEmptyValues(true)
+	if err := reloaded.LoadFromString(p.String()); err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if !reloaded.Equal(p) {
+		t.Errorf("reloaded %q, want %q", reloaded.String(), p.String())
+	}
+}
+
+// withInlineComments enables inline comments, which have no Option of
+// their own.
+func withInlineComments() Option {
+	return func(p *Parser) {
+		p.inlineComments = true
+	}
+}
+
+func TestValueRunsToEndOfLine(t *testing.T) {
+	const dsn = "user:pass@tcp(host:3306)/db?tls=true#frag"
+	tests := []struct {
+		name  string
+		opts  []Option
+		input string
+		want  string
+	}{
+		{"dsn", nil, "dsn=" + dsn, dsn},
+		{"dsn with spaces around separator", nil, "dsn = " + dsn, dsn},
+		{"hash after space", nil, "dsn=a #frag", "a #frag"},
+		{"inline comments keep hash without space", []Option{withInlineComments()}, "dsn=" + dsn, dsn},
+		{"inline comments strip hash after space", []Option{withInlineComments()}, "dsn=a #frag", "a"},
+		{"inline comments keep quoted hash", []Option{withInlineComments()}, `dsn="a #frag" # comment`, "a #frag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[db]\n"+tt.input, tt.opts...)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got, _ := p.Get("db", "dsn"); got != tt.want {
+				t.Fatalf("Get() = %q, want %q", got, tt.want)
+			}
+
+			path := filepath.Join(t.TempDir(), "db.ini")
+			if err := p.SaveToFile(path); err != nil {
+				t.Fatalf("SaveToFile() error = %v", err)
+			}
+			reloaded, err := ParseFile(path, tt.opts...)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			if got, _ := reloaded.Get("db", "dsn"); got != tt.want {
+				t.Errorf("Get() after save and reload = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzLoadFromString(f *testing.F) {
+	seeds := []string{
+		"",
+		"[owner]\nname=John Doe\norganization=Acme Widgets Inc.\n",
+		"[database]\nserver=192.0.2.62\nport=143\nfile=\"payroll.dat\"\n",
+		"global=1\n[s]\nk = v ; comment\n# note\n",
+		"[database\nport=5432",
+		"database]",
+		"=value",
+		"[]",
+		"[a\\]b]\n\"k=1\"=v\n",
+		"\uFEFF[s]\nk=v",
+		"[s]\nk=\"",
+		"[s]\n\"\\\"=",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		p := NewParser()
+		if err := p.LoadFromString(data); err != nil {
+			if len(p.GetSections()) != 0 {
+				t.Fatalf("LoadFromString(%q) failed with %v but loaded %v", data, err, p.GetSections())
+			}
+			return
+		}
+		out := p.String()
+		reloaded := NewParser()
+		if err := reloaded.LoadFromString(out); err != nil {
+			t.Fatalf("reload of %q (from %q) error = %v", out, data, err)
+		}
+		if !reloaded.Equal(p) {
+			t.Fatalf("reload of %q (from %q) = %v, want %v", out, data, reloaded.GetSections(), p.GetSections())
+		}
+	})
+}
+
+func TestZeroValueParser(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(p *Parser) error
+		want string
+	}{
+		{"Set", func(p *Parser) error { return p.Set("s", "k", "v") }, "[s]\nk=v\n"},
+		{"AddKey", func(p *Parser) error { return p.AddKey("s", "k", "v") }, "[s]\nk=v\n"},
+		{"SetSection", func(p *Parser) error { return p.SetSection("s", map[string]string{"k": "v"}) }, "[s]\nk=v\n"},
+		{"GetOrCreateSection", func(p *Parser) error { _, err := p.GetOrCreateSection("s"); return err }, "[s]\n"},
+		{"LoadFromString", func(p *Parser) error { return p.LoadFromString("[s]\nk=v") }, "[s]\nk=v\n"},
+		{"UpdateKey", func(p *Parser) error {
+			if err := p.UpdateKey("s", "k", "v"); !errors.Is(err, ErrSectionNotFound) {
+				return fmt.Errorf("UpdateKey() error = %v, want %v", err, ErrSectionNotFound)
+			}
+			return nil
+		}, ""},
+		{"DeleteSection", func(p *Parser) error {
+			if err := p.DeleteSection("s"); !errors.Is(err, ErrSectionNotFound) {
+				return fmt.Errorf("DeleteSection() error = %v, want %v", err, ErrSectionNotFound)
+			}
+			return nil
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Parser
+			if err := tt.call(&p); err != nil {
+				t.Fatal(err)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if _, err := p.Get("missing", "k"); !errors.Is(err, ErrSectionNotFound) {
+				t.Errorf("Get() error = %v, want %v", err, ErrSectionNotFound)
+			}
+		})
+	}
+}
+
+func TestUpdateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		key     string
+		wantErr error
+		want    string
+	}{
+		{"existing key", "s", "k", nil, "[s]\nk=new\n"},
+		{"missing key", "s", "typo", ErrKeyNotFound, "[s]\nk=v\n"},
+		{"missing section", "other", "k", ErrSectionNotFound, "[s]\nk=v\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\nk=v\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.UpdateKey(tt.section, tt.key, "new"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		key     string
+		wantErr error
+		want    string
+	}{
+		{"fresh key", "s", "new", nil, "[s]\nk=v\nnew=x\n"},
+		{"fresh section", "t", "new", nil, "[s]\nk=v\n\n[t]\nnew=x\n"},
+		{"existing key", "s", "k", ErrKeyAlreadyExists, "[s]\nk=v\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\nk=v\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.AddKey(tt.section, tt.key, "x"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIOCopy(t *testing.T) {
+	const input = "[database]\nport=143\n\n[owner]\nname=John\n"
+	sources := []struct {
+		name string
+		src  io.Reader
+	}{
+		{"single reader", struct{ io.Reader }{strings.NewReader(input)}},
+		{"chunked reader", struct{ io.Reader }{io.MultiReader(strings.NewReader(input[:12]), strings.NewReader(input[12:]))}},
+	}
+	for _, tt := range sources {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			// io.Copy needs an io.Writer destination; the failing Write
+			// proves it hands the whole source to ReadFrom instead.
+			dst := struct {
+				io.ReaderFrom
+				io.Writer
+			}{p, failingWriter{}}
+			n, err := io.Copy(dst, tt.src)
+			if err != nil {
+				t.Fatalf("io.Copy() error = %v", err)
+			}
+			if n != int64(len(input)) {
+				t.Errorf("io.Copy() = %d bytes, want %d", n, len(input))
+			}
+			if got := p.String(); got != input {
+				t.Errorf("String() = %q, want %q", got, input)
+			}
+		})
+	}
+}
+
+func TestReadFrom(t *testing.T) {
+	p := NewParser()
+	n, err := p.ReadFrom(strings.NewReader("[s]\nk=v\n"))
+	if err != nil || n != 8 {
+		t.Fatalf("ReadFrom() = %d, %v, want 8, nil", n, err)
+	}
+	if got, _ := p.Get("s", "k"); got != "v" {
+		t.Errorf("Get(s, k) = %q, want v", got)
+	}
+	if _, err := p.ReadFrom(strings.NewReader("[broken\n")); !errors.Is(err, ErrMalformedSectionHeader) {
+		t.Errorf("ReadFrom() error = %v, want %v", err, ErrMalformedSectionHeader)
+	}
+	if got, _ := p.Get("s", "k"); got != "v" {
+		t.Errorf("Get(s, k) after a failed ReadFrom = %q, want the previous value", got)
+	}
+}
+
+func TestRenameKey(t *testing.T) {
+	const input = "[db]\n; primary host\ndbhost=a\nport=5432\n"
+	tests := []struct {
+		name     string
+		opts     []Option
+		section  string
+		old, new string
+		wantErr  error
+		want     string
+	}{
+		{"success", []Option{WithPreserveComments()}, "db", "dbhost", "host", nil, "[db]\n; primary host\nhost=a\nport=5432\n"},
+		{"missing old key", nil, "db", "user", "login", ErrKeyNotFound, "[db]\ndbhost=a\nport=5432\n"},
+		{"colliding new key", nil, "db", "dbhost", "port", ErrKeyAlreadyExists, "[db]\ndbhost=a\nport=5432\n"},
+		{"missing section", nil, "cache", "dbhost", "host", ErrSectionNotFound, "[db]\ndbhost=a\nport=5432\n"},
+		{"empty new key", nil, "db", "dbhost", "", ErrKeyIsEmpty, "[db]\ndbhost=a\nport=5432\n"},
+		{"case-insensitive respelling", []Option{WithCaseInsensitive()}, "db", "DBHOST", "DbHost", nil, "[db]\nDbHost=a\nport=5432\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(input, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.RenameKey(tt.section, tt.old, tt.new); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RenameKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveTo(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		input string
+	}{
+		{"plain", nil, "[b]\nk=v\n\n[a]\nx=1\n"},
+		{"with comments", []Option{WithPreserveComments()}, "; top\n[a]\n; about x\nx=1\n"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.input, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf strings.Builder
+			if err := p.SaveTo(&buf); err != nil {
+				t.Fatalf("SaveTo() error = %v", err)
+			}
+			if got, want := buf.String(), p.String(); got != want {
+				t.Errorf("SaveTo() wrote %q, String() = %q", got, want)
+			}
+
+			buf.Reset()
+			n, err := p.WriteTo(&buf)
+			if err != nil || n != int64(buf.Len()) {
+				t.Errorf("WriteTo() = %d, %v, wrote %d bytes", n, err, buf.Len())
+			}
+
+			path := filepath.Join(t.TempDir(), "out.ini")
+			if err := p.SaveToFile(path); err != nil {
+				t.Fatalf("SaveToFile() error = %v", err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != buf.String() {
+				t.Errorf("SaveToFile() wrote %q, WriteTo() %q", content, buf.String())
+			}
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+
+func TestSaveToWriteError(t *testing.T) {
+	p, err := Parse("[s]\nk=v\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SaveTo(failingWriter{}); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("SaveTo() error = %v, want %v", err, io.ErrClosedPipe)
+	}
+}
+
+func TestStringAligned(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		input string
+		delim string
+		want  string
+	}{
+		{
+			name:  "per-section columns",
+			input: "[owner]\nname=John\ncountry=Egypt\n[db]\nport=1\nk=2\n",
+			want:  "[db]\nk    = 2\nport = 1\n\n[owner]\ncountry = Egypt\nname    = John\n",
+		},
+		{
+			name:  "quoted key counts its quotes",
+			input: "[s]\n\"a=b\"=1\nlongkey=2\n",
+			want:  "[s]\n\"a=b\"   = 1\nlongkey = 2\n",
+		},
+		{
+			name:  "output delimiter",
+			input: "[s]\na=1\nbbb=2\n",
+			delim: "=",
+			want:  "[s]\na  =1\nbbb=2\n",
+		},
+		{
+			name:  "custom separator",
+			opts:  []Option{WithSeparator(':')},
+			input: "[s]\na:1\nbbb:2\n",
+			want:  "[s]\na   : 1\nbbb : 2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.input, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.SetOutputDelimiter(tt.delim)
+			got := p.StringAligned()
+			if got != tt.want {
+				t.Fatalf("StringAligned() = %q, want %q", got, tt.want)
+			}
+			reloaded := NewParser(tt.opts...)
+			if err := reloaded.LoadFromString(got); err != nil {
+				t.Fatalf("reloading %q: %v", got, err)
+			}
+			if !reloaded.Equal(p) {
+				t.Errorf("reloaded %v, want %v", reloaded.GetSections(), p.GetSections())
+			}
+		})
+	}
+}
+
+func TestLoadEmptyFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		content string
+		wantErr error
+	}{
+		{"zero bytes", false, "", nil},
+		{"blank lines", false, "\n  \n\t\n", nil},
+		{"comments only", false, "; nothing yet\n", nil},
+		{"zero bytes, strict", true, "", ErrEmptyString},
+		{"blank lines, strict", true, "\n  \n", ErrEmptyString},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[old]\nk=v\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.SetStrict(tt.strict)
+			err = p.LoadFromFile(writeFiles(t, tt.content)[0])
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromFile() error = %v, want %v", err, tt.wantErr)
+			}
+			wantSections := 0
+			if err != nil {
+				wantSections = 1
+			}
+			if got := p.SectionCount(); got != wantSections {
+				t.Errorf("SectionCount() = %d, want %d", got, wantSections)
+			}
+		})
+	}
+}
+
+func TestGetSectionNamesSorted(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(p *Parser) error
+		want  []string
+	}{
+		{"loaded", func(p *Parser) error {
+			return p.LoadFromString("k=v\n[gamma]\nk=v\n[alpha]\nk=v\n[Zeta]\nk=v\n[beta]\nk=v\n")
+		}, []string{"", "Zeta", "alpha", "beta", "gamma"}},
+		{"set", func(p *Parser) error {
+			for _, name := range []string{"beta", "Zeta", "gamma", "alpha"} {
+				if err := p.Set(name, "k", "v"); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, []string{"Zeta", "alpha", "beta", "gamma"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			if err := tt.build(p); err != nil {
+				t.Fatal(err)
+			}
+			for range 5 {
+				if got := p.GetSectionNames(); !slices.Equal(got, tt.want) {
+					t.Fatalf("GetSectionNames() = %q, want %q", got, tt.want)
+				}
+			}
+			if got := p.GetSortedSectionNames(); !slices.Equal(got, tt.want) {
+				t.Errorf("GetSortedSectionNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptySectionsSurvive(t *testing.T) {
+	const input = "[empty]\n\n[s]\nk=v\n\n[also empty]\n"
+	p, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"also empty", "empty", "s"}
+	if got := p.GetSectionNames(); !slices.Equal(got, want) {
+		t.Fatalf("GetSectionNames() = %q, want %q", got, want)
+	}
+	out := p.String()
+	for _, header := range []string{"[empty]\n", "[also empty]\n"} {
+		if !strings.Contains(out, header) {
+			t.Errorf("String() = %q, missing %q", out, header)
+		}
+	}
+	reloaded, err := Parse(out)
+	if err != nil {
+		t.Fatalf("reloading %q: %v", out, err)
+	}
+	if got := reloaded.GetSectionNames(); !slices.Equal(got, want) {
+		t.Errorf("GetSectionNames() after reload = %q, want %q", got, want)
+	}
+	if n, err := reloaded.KeyCount("empty"); err != nil || n != 0 {
+		t.Errorf("KeyCount(empty) = %d, %v, want 0", n, err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := writeFiles(t, "[s]\nk=disk\n")[0]
+	p, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set("s", "k", "edited"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set("s", "extra", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got, _ := p.Get("s", "k"); got != "disk" {
+		t.Errorf("Get(s, k) after Reload = %q, want disk", got)
+	}
+	if _, err := p.Get("s", "extra"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get(s, extra) after Reload error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if p.IsDirty() {
+		t.Error("IsDirty() after Reload = true")
+	}
+
+	if err := os.WriteFile(path, []byte("[s]\nk=changed on disk\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got, _ := p.Get("s", "k"); got != "changed on disk" {
+		t.Errorf("Get(s, k) after second Reload = %q, want the new file content", got)
+	}
+}
+
+func TestReloadWithoutFile(t *testing.T) {
+	p, err := Parse("[s]\nk=v\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reload(); !errors.Is(err, ErrNoFileLoaded) {
+		t.Errorf("Reload() error = %v, want %v", err, ErrNoFileLoaded)
+	}
+	if err := NewParser().Reload(); !errors.Is(err, ErrNoFileLoaded) {
+		t.Errorf("Reload() on a new parser error = %v, want %v", err, ErrNoFileLoaded)
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	path := writeFiles(t, "[s]\nk=v\n")[0]
+	p := NewParser()
+	if got := p.FilePath(); got != "" {
+		t.Errorf("FilePath() on a new parser = %q, want empty", got)
+	}
+	if err := p.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.FilePath(); got != path {
+		t.Errorf("FilePath() after LoadFromFile = %q, want %q", got, path)
+	}
+	if err := p.LoadFromString("[s]\nk=v\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.FilePath(); got != "" {
+		t.Errorf("FilePath() after LoadFromString = %q, want empty", got)
+	}
+	if err := p.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadFromFile(filepath.Join(t.TempDir(), "missing.ini")); err == nil {
+		t.Fatal("LoadFromFile() of a missing file succeeded")
+	}
+	if got := p.FilePath(); got != path {
+		t.Errorf("FilePath() after a failed load = %q, want %q", got, path)
+	}
+}
+
+func TestSetLineEnding(t *testing.T) {
+	const input = "; note\n[a]\nk=v\n\n[b]\nx=1\n"
+	tests := []struct {
+		name    string
+		ending  string
+		want    string
+		wantErr error
+	}{
+		{"crlf", "\r\n", "; note\r\n[a]\r\nk=v\r\n\r\n[b]\r\nx=1\r\n", nil},
+		{"lf", "\n", input, nil},
+		{"bare cr", "\r", input, ErrInvalidLineEnding},
+		{"empty", "", input, ErrInvalidLineEnding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(input, WithPreserveComments())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.SetLineEnding(tt.ending); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetLineEnding() error = %v, want %v", err, tt.wantErr)
+			}
+			path := filepath.Join(t.TempDir(), "out.ini")
+			if err := p.SaveToFile(path); err != nil {
+				t.Fatalf("SaveToFile() error = %v", err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("SaveToFile() wrote %q, want %q", content, tt.want)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			var buf strings.Builder
+			if n, err := p.WriteTo(&buf); err != nil || n != int64(len(tt.want)) {
+				t.Errorf("WriteTo() = %d, %v, want %d bytes", n, err, len(tt.want))
+			}
+
+			reloaded, err := ParseFile(path, WithPreserveComments())
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			if !reloaded.Equal(p) {
+				t.Errorf("reloaded %v, want %v", reloaded.GetSections(), p.GetSections())
+			}
+		})
+	}
+}
+
+func TestDefaultSection(t *testing.T) {
+	const input = "[DEFAULT]\nport=80\nhost=localhost\n\n[web]\nport=8080\n\n[api]\nname=api\n"
+	tests := []struct {
+		name    string
+		opts    []Option
+		section string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"override", []Option{WithDefaultSection("DEFAULT")}, "web", "port", "8080", nil},
+		{"inherited", []Option{WithDefaultSection("DEFAULT")}, "api", "port", "80", nil},
+		{"inherited other key", []Option{WithDefaultSection("DEFAULT")}, "web", "host", "localhost", nil},
+		{"own key", []Option{WithDefaultSection("DEFAULT")}, "api", "name", "api", nil},
+		{"missing everywhere", []Option{WithDefaultSection("DEFAULT")}, "api", "user", "", ErrKeyNotFound},
+		{"missing section", []Option{WithDefaultSection("DEFAULT")}, "db", "port", "", ErrSectionNotFound},
+		{"disabled", nil, "api", "port", "", ErrKeyNotFound},
+		{"case-insensitive", []Option{WithDefaultSection("default"), WithCaseInsensitive()}, "API", "PORT", "80", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(input, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Get(tt.section, tt.key)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Get(%q, %q) = %q, %v, want %q, %v", tt.section, tt.key, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultSectionNotCopied(t *testing.T) {
+	const input = "[DEFAULT]\nport=80\n\n[api]\nname=api\n"
+	p, err := Parse(input, WithDefaultSection("DEFAULT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.GetSections()["api"]; !maps.Equal(got, map[string]string{"name": "api"}) {
+		t.Errorf("GetSections()[api] = %v, want only its own keys", got)
+	}
+	if got := p.String(); got != "[DEFAULT]\nport=80\n\n[api]\nname=api\n" {
+		t.Errorf("String() = %q", got)
+	}
+	if n, _ := p.GetInt("api", "port"); n != 80 {
+		t.Errorf("GetInt(api, port) = %d, want 80", n)
+	}
+	if got, _ := p.GetRaw("api", "port"); got != "80" {
+		t.Errorf("GetRaw(api, port) = %q, want 80", got)
+	}
+
+	p.SetDefaultSection("")
+	if _, err := p.Get("api", "port"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get() after disabling error = %v, want %v", err, ErrKeyNotFound)
+	}
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   string
+		key       string
+		wantWrote bool
+		wantErr   error
+		want      string
+	}{
+		{"absent key", "s", "new", true, nil, "[s]\nk=v\nnew=default\n"},
+		{"existing key kept", "s", "k", false, nil, "[s]\nk=v\n"},
+		{"section created", "t", "new", true, nil, "[s]\nk=v\n\n[t]\nnew=default\n"},
+		{"empty section", "", "new", false, ErrSectionIsEmpty, "[s]\nk=v\n"},
+		{"empty key", "s", "", false, ErrKeyIsEmpty, "[s]\nk=v\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\nk=v\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			wrote, err := p.SetIfAbsent(tt.section, tt.key, "default")
+			if !errors.Is(err, tt.wantErr) || wrote != tt.wantWrote {
+				t.Fatalf("SetIfAbsent() = %v, %v, want %v, %v", wrote, err, tt.wantWrote, tt.wantErr)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromStringWithWarnings(t *testing.T) {
+	const input = "[s]\nk=1\nk=2\njunk\n=x\ne=\nok=yes\n"
+	tests := []struct {
+		name     string
+		strategy DuplicateStrategy
+		want     string
+	}{
+		{"last", DuplicateLast, "2"},
+		{"first", DuplicateFirst, "1"},
+		{"error keeps the first", DuplicateError, "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(WithDuplicateStrategy(tt.strategy))
+			warnings, err := p.LoadFromStringWithWarnings(input)
+			if err != nil {
+				t.Fatalf("LoadFromStringWithWarnings() error = %v", err)
+			}
+			lines := make([]int, len(warnings))
+			for i, w := range warnings {
+				lines[i] = w.Line
+			}
+			if want := []int{3, 4, 5, 6}; !slices.Equal(lines, want) {
+				t.Errorf("warnings on lines %v, want %v: %q", lines, want, warnings)
+			}
+			if got, _ := p.Get("s", "k"); got != tt.want {
+				t.Errorf("Get(s, k) = %q, want %q", got, tt.want)
+			}
+			if got, _ := p.Get("s", "ok"); got != "yes" {
+				t.Errorf("Get(s, ok) = %q, want yes", got)
+			}
+			if _, err := p.Get("s", "e"); !errors.Is(err, ErrKeyNotFound) {
+				t.Errorf("Get(s, e) error = %v, want the line skipped", err)
+			}
+		})
+	}
+}
+
+func TestLoadFromStringWithWarningsFatal(t *testing.T) {
+	p, err := Parse("[keep]\nk=v\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings, err := p.LoadFromStringWithWarnings("[s]\nempty=\n[broken\nk=v\n")
+	if !errors.Is(err, ErrMalformedSectionHeader) {
+		t.Fatalf("LoadFromStringWithWarnings() error = %v, want %v", err, ErrMalformedSectionHeader)
+	}
+	if len(warnings) != 1 || warnings[0].Line != 2 {
+		t.Errorf("warnings = %q, want the one before the failure", warnings)
+	}
+	if got := p.String(); got != "[keep]\nk=v\n" {
+		t.Errorf("String() = %q, want the content unchanged", got)
+	}
+}
+
+func TestLoadFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.ini": &fstest.MapFile{Data: []byte("[server]\nport=8080\n")},
+	}
+	tests := []struct {
+		name    string
+		file    string
+		want    string
+		wantErr error
+	}{
+		{"present", "config/app.ini", "8080", nil},
+		{"missing", "config/other.ini", "1", fs.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[server]\nport=1\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.LoadFromFS(fsys, tt.file); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromFS() error = %v, want %v", err, tt.wantErr)
+			}
+			if got, _ := p.Get("server", "port"); got != tt.want {
+				t.Errorf("Get(server, port) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettersTrimSectionName(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(p *Parser, section string) error
+	}{
+		{"Set", func(p *Parser, section string) error { return p.Set(section, "k", "v") }},
+		{"AddKey", func(p *Parser, section string) error { return p.AddKey(section, "k", "v") }},
+		{"SetSection", func(p *Parser, section string) error {
+			return p.SetSection(section, map[string]string{"k": "v"})
+		}},
+		{"GetOrCreateSection", func(p *Parser, section string) error {
+			s, err := p.GetOrCreateSection(section)
+			if err != nil {
+				return err
+			}
+			return s.Set("k", "v")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			if err := tt.call(p, " x "); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := p.GetSectionNames(), []string{"x"}; !slices.Equal(got, want) {
+				t.Fatalf("GetSectionNames() = %q, want %q", got, want)
+			}
+			reloaded, err := Parse(p.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reloaded.Equal(p) {
+				t.Errorf("reloaded %q, want %q", reloaded.String(), p.String())
+			}
+			if err := tt.call(p, "  "); !errors.Is(err, ErrSectionIsEmpty) {
+				t.Errorf("blank section error = %v, want %v", err, ErrSectionIsEmpty)
+			}
+		})
+	}
+}
+
+func TestCompact(t *testing.T) {
+	p, err := Parse("[owner]\nname=John Doe\norg=Acme\n\n[database]\nserver=192.0.2.62\nport=143\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "database{port=143;server=192.0.2.62} owner{name=John Doe;org=Acme}"
+	for i := 0; i < 10; i++ {
+		if got := p.Compact(); got != want {
+			t.Fatalf("Compact() = %q, want %q", got, want)
+		}
+	}
+
+	// Setters reject line breaks, so store them directly to check that
+	// they are escaped.
+	p.parsedData["owner"]["note"] = "line one\r\nline two"
+	p.parsedData["owner"]["path"] = `C:\n`
+	got := p.Compact()
+	if strings.ContainsAny(got, "\r\n") {
+		t.Fatalf("Compact() = %q, want a single line", got)
+	}
+	if want := `owner{name=John Doe;note=line one\r\nline two;org=Acme;path=C:\\n}`; !strings.HasSuffix(got, want) {
+		t.Errorf("Compact() = %q, want it to end with %q", got, want)
+	}
+}
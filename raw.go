@@ -0,0 +1,54 @@
+package iniparser
+
+// GetRaw returns the value of key in section as written in the loaded
+// input, before surrounding quotes and, with inline comments enabled, the
+// trailing comment were removed; for `name = "John Doe" ; owner` it returns
+// `"John Doe" ; owner` where Get returns `John Doe`. Surrounding whitespace
+// is trimmed. For keys set through the API it returns the value as set.
+// Errors are the same as Get's.
+func (p *Parser) GetRaw(section, key string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	section, key, err := p.lookup(section, key)
+	if err != nil {
+		return "", err
+	}
+	if raw, ok := p.raw[section][key]; ok {
+		return raw, nil
+	}
+	return p.parsedData[section][key], nil
+}
+
+// keepRaw records raw as the text key in section was loaded from, unless
+// it is the same as the stored value.
+func (lp *lineParser) keepRaw(section, key, raw, value string) {
+	if raw == value {
+		delete(lp.raw[section], key)
+		return
+	}
+	if lp.raw == nil {
+		lp.raw = make(map[string]map[string]string)
+	}
+	if lp.raw[section] == nil {
+		lp.raw[section] = make(map[string]string)
+	}
+	lp.raw[section][key] = raw
+}
+
+// moveRaw re-files the raw text of key from in section under key to. It
+// must be called with p.mu held.
+func (p *Parser) moveRaw(section, from, to string) {
+	section, _ = lookupName(p.raw, section, p.caseInsensitive)
+	if raw, ok := p.raw[section][from]; ok {
+		delete(p.raw[section], from)
+		p.raw[section][to] = raw
+	}
+}
+
+// dropRaw forgets the raw text of key in section once its value has been
+// replaced. It must be called with p.mu held.
+func (p *Parser) dropRaw(section, key string) {
+	section, _ = lookupName(p.raw, section, p.caseInsensitive)
+	delete(p.raw[section], key)
+}
@@ -0,0 +1,83 @@
+package iniparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetRaw(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		input   string
+		key     string
+		want    string
+		wantGet string
+	}{
+		{"plain", nil, "k = plain", "k", "plain", "plain"},
+		{"quoted", nil, `k = "  padded "`, "k", `"  padded "`, "  padded "},
+		{"inline comment", []Option{withInlineComments()}, `k = "John Doe" ; owner`, "k", `"John Doe" ; owner`, "John Doe"},
+		{"hash kept without inline comments", nil, "k = a # b", "k", "a # b", "a # b"},
+		{"case-insensitive", []Option{WithCaseInsensitive()}, `Key = "v"`, "KEY", `"v"`, "v"},
+		{"last duplicate", nil, "k = \"1\"\nk = 2", "k", "2", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\n"+tt.input, tt.opts...)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got, err := p.GetRaw("s", tt.key); err != nil || got != tt.want {
+				t.Errorf("GetRaw() = %q, %v, want %q", got, err, tt.want)
+			}
+			if got, _ := p.Get("s", tt.key); got != tt.wantGet {
+				t.Errorf("Get() = %q, want %q", got, tt.wantGet)
+			}
+		})
+	}
+}
+
+func TestGetRawAfterEdits(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(p *Parser) error
+		key  string
+		want string
+	}{
+		{"Set", func(p *Parser) error { return p.Set("s", "k", `"new"`) }, "k", `"new"`},
+		{"UpdateKey", func(p *Parser) error { return p.UpdateKey("s", "k", "new") }, "k", "new"},
+		{"RenameKey", func(p *Parser) error { return p.RenameKey("s", "k", "renamed") }, "renamed", `"old"`},
+		{"ReplaceValue", func(p *Parser) error { p.ReplaceValue("old", "new"); return nil }, "k", "new"},
+		{"SetSection", func(p *Parser) error { return p.SetSection("s", map[string]string{"k": "new"}) }, "k", "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[s]\nk = \"old\"")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.edit(p); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := p.GetRaw("s", tt.key); err != nil || got != tt.want {
+				t.Errorf("GetRaw() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRawErrors(t *testing.T) {
+	p, err := Parse("[DEFAULT]\nport = \"80\"\n[s]\nk = v", WithDefaultSection("DEFAULT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetRaw("missing", "k"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("GetRaw() error = %v, want %v", err, ErrSectionNotFound)
+	}
+	if _, err := p.GetRaw("s", "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("GetRaw() error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if got, err := p.GetRaw("s", "port"); err != nil || got != `"80"` {
+		t.Errorf("GetRaw() of inherited key = %q, %v, want %q", got, err, `"80"`)
+	}
+}
@@ -0,0 +1,85 @@
+package iniparser
+
+import "strings"
+
+// Section is a handle on one section of a Parser. Its methods behave like
+// the Parser methods of the same name with the section already filled in,
+// and they see later changes made through the parser.
+type Section struct {
+	p    *Parser
+	name string
+}
+
+// Section returns a handle on the named section, or ErrSectionNotFound if
+// it does not exist.
+func (p *Parser) Section(name string) (*Section, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	name, ok := lookupName(p.parsedData, name, p.caseInsensitive)
+	if !ok {
+		return nil, ErrSectionNotFound
+	}
+	return &Section{p: p, name: name}, nil
+}
+
+// GetOrCreateSection returns a handle on the named section, creating it
+// empty if it does not exist yet. Spaces around name are trimmed, as they
+// are in a section header.
+func (p *Parser) GetOrCreateSection(name string) (*Section, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrSectionIsEmpty
+	}
+	if err := checkLineBreaks(name); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.section(name); !ok {
+		p.ensureSection(name)
+		p.markDirty(name)
+	}
+	name, _ = lookupName(p.parsedData, name, p.caseInsensitive)
+	return &Section{p: p, name: name}, nil
+}
+
+// Name returns the name of the section.
+func (s *Section) Name() string {
+	return s.name
+}
+
+// Get returns the value of key in the section.
+func (s *Section) Get(key string) (string, error) {
+	return s.p.Get(s.name, key)
+}
+
+// GetInt returns the value of key in the section parsed as an int.
+func (s *Section) GetInt(key string) (int, error) {
+	return s.p.GetInt(s.name, key)
+}
+
+// GetBool returns the value of key in the section parsed as a bool.
+func (s *Section) GetBool(key string) (bool, error) {
+	return s.p.GetBool(s.name, key)
+}
+
+// Set stores value under key in the section.
+func (s *Section) Set(key, value string) error {
+	return s.p.Set(s.name, key, value)
+}
+
+// Keys returns the key names of the section in lexicographic order. It
+// returns nil if the section has since been deleted.
+func (s *Section) Keys() []string {
+	s.p.mu.RLock()
+	defer s.p.mu.RUnlock()
+
+	keys, ok := s.p.section(s.name)
+	if !ok {
+		return nil
+	}
+	return sortedKeys(keys)
+}
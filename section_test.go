@@ -0,0 +1,93 @@
+package iniparser
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSection(t *testing.T) {
+	p, err := Parse("[server]\nhost=example.com\nport=8080\ndebug=true\nname=x\n", WithCaseInsensitive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.Section("SERVER")
+	if err != nil {
+		t.Fatalf("Section() error = %v", err)
+	}
+	if s.Name() != "server" {
+		t.Errorf("Name() = %q, want the stored spelling %q", s.Name(), "server")
+	}
+	if got, err := s.Get("host"); err != nil || got != "example.com" {
+		t.Errorf("Get(host) = %q, %v", got, err)
+	}
+	if got, err := s.GetInt("port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(port) = %d, %v", got, err)
+	}
+	if got, err := s.GetBool("debug"); err != nil || !got {
+		t.Errorf("GetBool(debug) = %v, %v", got, err)
+	}
+	if _, err := s.GetInt("name"); !errors.Is(err, ErrValueNotInteger) {
+		t.Errorf("GetInt(name) error = %v, want %v", err, ErrValueNotInteger)
+	}
+	if _, err := s.Get("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get(missing) error = %v, want %v", err, ErrKeyNotFound)
+	}
+
+	if err := s.Set("timeout", "30s"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := p.Get("server", "timeout"); got != "30s" {
+		t.Errorf("parser does not see Set through the handle: %q", got)
+	}
+	if err := p.Set("server", "port", "9090"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetInt("port"); got != 9090 {
+		t.Errorf("handle does not see Set through the parser: %d", got)
+	}
+	if got, want := s.Keys(), []string{"debug", "host", "name", "port", "timeout"}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %q, want %q", got, want)
+	}
+
+	if err := p.DeleteSection("server"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Keys(); got != nil {
+		t.Errorf("Keys() after DeleteSection = %q, want nil", got)
+	}
+}
+
+func TestSectionNotFound(t *testing.T) {
+	p, err := Parse("[s]\nk=v\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Section("S"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Section() error = %v, want %v", err, ErrSectionNotFound)
+	}
+}
+
+func TestGetOrCreateSection(t *testing.T) {
+	p, err := Parse("[s]\nk=v\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.GetOrCreateSection("s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get("k"); got != "v" {
+		t.Errorf("existing section Get(k) = %q, want v", got)
+	}
+	created, err := p.GetOrCreateSection("new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := created.Keys(); len(keys) != 0 || !slices.Contains(p.GetSectionNames(), "new") {
+		t.Errorf("created section has keys %q, sections %q", keys, p.GetSectionNames())
+	}
+	if _, err := p.GetOrCreateSection(""); !errors.Is(err, ErrSectionIsEmpty) {
+		t.Errorf("GetOrCreateSection(\"\") error = %v, want %v", err, ErrSectionIsEmpty)
+	}
+}
@@ -0,0 +1,385 @@
+package iniparser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Filter returns a new parser holding deep copies of the sections whose
+// name satisfies pred. The result shares no state with p.
+func (p *Parser) Filter(pred func(section string) bool) *Parser {
+	return p.FilterSections(func(name string, _ map[string]string) bool {
+		return pred(name)
+	})
+}
+
+// FilterSections is like Filter but also passes pred the keys of each
+// section, so sections can be picked by their content, such as those with
+// "type=service". pred receives a copy and may keep or change it freely.
+// The comments, multiple values and raw text of the kept sections are
+// copied along with their keys.
+func (p *Parser) FilterSections(pred func(name string, kv map[string]string) bool) *Parser {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	filtered := p.emptyCopy()
+	for name, keys := range p.parsedData {
+		if kv := copySection(keys); pred(name, kv) {
+			filtered.copySectionFrom(&p.document, name)
+		}
+	}
+	return filtered
+}
+
+// SectionsWithPrefix returns a copy of every section whose name starts with
+// prefix, keyed by the full section name.
+func (p *Parser) SectionsWithPrefix(prefix string) map[string]map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sections := make(map[string]map[string]string)
+	for name, keys := range p.parsedData {
+		if p.hasPrefix(name, prefix) {
+			sections[name] = copySection(keys)
+		}
+	}
+	return sections
+}
+
+// GetSectionNamesWithPrefix returns the names of the sections that start
+// with prefix, in lexicographic order. Unlike SectionsWithPrefix it copies
+// no keys.
+func (p *Parser) GetSectionNamesWithPrefix(prefix string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := []string{}
+	for _, name := range sortedKeys(p.parsedData) {
+		if p.hasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SectionKeys names a section and its keys.
+type SectionKeys struct {
+	Name string
+	Keys []string
+}
+
+// OrderedSections lists every section with its keys, both in lexicographic
+// order, for deterministic iteration without access to the underlying
+// maps.
+func (p *Parser) OrderedSections() []SectionKeys {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := sortedKeys(p.parsedData)
+	sections := make([]SectionKeys, len(names))
+	for i, name := range names {
+		sections[i] = SectionKeys{Name: name, Keys: sortedKeys(p.parsedData[name])}
+	}
+	return sections
+}
+
+// ForEachSorted calls fn for every key, visiting sections and then the keys
+// within each in lexicographic order, and stops at the first error fn
+// returns, which it returns. A multi-value key is visited once per value.
+// fn runs on a snapshot, so it may modify p.
+func (p *Parser) ForEachSorted(fn func(section, key, value string) error) error {
+	type entry struct{ section, key, value string }
+
+	p.mu.RLock()
+	var entries []entry
+	for _, name := range sortedKeys(p.parsedData) {
+		keys := p.parsedData[name]
+		for _, key := range sortedKeys(keys) {
+			values, ok := p.values[name][key]
+			if !ok {
+				values = []string{keys[key]}
+			}
+			for _, value := range values {
+				entries = append(entries, entry{name, key, value})
+			}
+		}
+	}
+	p.mu.RUnlock()
+
+	for _, e := range entries {
+		if err := fn(e.section, e.key, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAll returns the value of key in every section that defines it, keyed by
+// section name.
+func (p *Parser) GetAll(key string) map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	values := make(map[string]string)
+	for name, keys := range p.parsedData {
+		if k, ok := lookupName(keys, key, p.caseInsensitive); ok {
+			values[name] = keys[k]
+		}
+	}
+	return values
+}
+
+// FindValue returns the location of every key whose value is exactly
+// value, as "section.key" ("key" alone for the unnamed section), ordered
+// by section and then key. A multi-value key matches if any of its values
+// does.
+func (p *Parser) FindValue(value string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var found []string
+	for _, name := range sortedKeys(p.parsedData) {
+		keys := p.parsedData[name]
+		for _, key := range sortedKeys(keys) {
+			values, ok := p.values[name][key]
+			if !ok {
+				values = []string{keys[key]}
+			}
+			if !slices.Contains(values, value) {
+				continue
+			}
+			if name != "" {
+				key = name + "." + key
+			}
+			found = append(found, key)
+		}
+	}
+	return found
+}
+
+// GetMap collects the keys of section that start with prefix followed by
+// sep, such as "labels.env" and "labels.tier" for prefix "labels" and sep
+// ".", and returns their values keyed by the rest of the name ("env",
+// "tier"). It returns an empty map if no key matches.
+func (p *Parser) GetMap(section, prefix, sep string) (map[string]string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys, ok := p.section(section)
+	if !ok {
+		return nil, ErrSectionNotFound
+	}
+	values := make(map[string]string)
+	for key, value := range keys {
+		if p.hasPrefix(key, prefix+sep) {
+			values[key[len(prefix+sep):]] = value
+		}
+	}
+	return values, nil
+}
+
+// hasPrefix reports whether name starts with prefix, ignoring case in
+// case-insensitive mode.
+func (p *Parser) hasPrefix(name, prefix string) bool {
+	if p.caseInsensitive {
+		return len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)
+	}
+	return strings.HasPrefix(name, prefix)
+}
+
+// SectionCount returns the number of sections, including the unnamed one
+// when it holds keys.
+func (p *Parser) SectionCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.parsedData)
+}
+
+// KeyCount returns the number of keys in section, or ErrSectionNotFound if
+// the section does not exist.
+func (p *Parser) KeyCount(section string) (int, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys, ok := p.section(section)
+	if !ok {
+		return 0, ErrSectionNotFound
+	}
+	return len(keys), nil
+}
+
+// TotalKeyCount returns the number of keys across all sections.
+func (p *Parser) TotalKeyCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	total := 0
+	for _, keys := range p.parsedData {
+		total += len(keys)
+	}
+	return total
+}
+
+// MergeSection copies the keys of section in other into the same section of
+// p, creating it if needed. Keys present in both take other's value; keys
+// only in p are kept. It returns ErrSectionNotFound if other has no such
+// section.
+func (p *Parser) MergeSection(section string, other *Parser) error {
+	other.mu.RLock()
+	keys, ok := other.section(section)
+	keys = copySection(keys)
+	other.mu.RUnlock()
+	if !ok {
+		return ErrSectionNotFound
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	dst := p.ensureSection(section)
+	for key, value := range keys {
+		key, _ = lookupName(dst, key, p.caseInsensitive)
+		dst[key] = value
+		p.dropValues(section, key)
+		p.dropRaw(section, key)
+	}
+	p.markDirty(section)
+	return nil
+}
+
+// SetSection stores every key of values in section, creating the section if
+// needed. Other keys of the section are kept. Empty keys, and empty values
+// unless AllowEmptyValues is enabled, and line breaks are rejected before
+// anything is written. The section name is trimmed as by Set.
+func (p *Parser) SetSection(section string, values map[string]string) error {
+	section = strings.TrimSpace(section)
+	if section == "" {
+		return ErrSectionIsEmpty
+	}
+	if err := checkLineBreaks(section); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, value := range values {
+		if key == "" {
+			return ErrKeyIsEmpty
+		}
+		if value == "" && !p.allowEmptyValues {
+			return fmt.Errorf("%w: %q", ErrValueIsEmpty, key)
+		}
+		if err := checkLineBreaks(key, value); err != nil {
+			return err
+		}
+	}
+	keys := p.ensureSection(section)
+	for key, value := range values {
+		key, _ = lookupName(keys, key, p.caseInsensitive)
+		keys[key] = value
+		p.dropValues(section, key)
+		p.dropRaw(section, key)
+	}
+	p.markDirty(section)
+	return nil
+}
+
+// SetSectionDiff reports what SetSection(section, values) would do without
+// changing anything: added lists the keys it would create and changed the
+// existing keys whose value it would replace, both sorted. Keys that
+// already hold the same value appear in neither.
+func (p *Parser) SetSectionDiff(section string, values map[string]string) (added, changed []string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys, _ := p.section(section)
+	for _, key := range sortedKeys(values) {
+		existing, ok := lookupName(keys, key, p.caseInsensitive)
+		switch {
+		case !ok:
+			added = append(added, key)
+		case keys[existing] != values[key]:
+			changed = append(changed, key)
+		}
+	}
+	return added, changed
+}
+
+// ReplaceValue sets every value equal to old, in any section and key, to
+// new, and returns the number of values replaced. Every value of a
+// multi-value key is checked. Nothing is replaced if new contains a line
+// break, and a value is left alone if replacing would empty it while
+// AllowEmptyValues is disabled, as Set would refuse it.
+func (p *Parser) ReplaceValue(old, new string) int {
+	if checkLineBreaks(new) != nil {
+		return 0
+	}
+	return p.replaceValues(func(value string) string {
+		if value == old {
+			return new
+		}
+		return value
+	})
+}
+
+// ReplaceValueSubstring is like ReplaceValue but replaces every occurrence
+// of old within a value. It returns the number of values that changed.
+func (p *Parser) ReplaceValueSubstring(old, new string) int {
+	if old == "" || checkLineBreaks(new) != nil {
+		return 0
+	}
+	return p.replaceValues(func(value string) string {
+		return strings.ReplaceAll(value, old, new)
+	})
+}
+
+// replaceValues passes every value through replace and stores the result,
+// returning how many values changed. Empty results are dropped unless
+// empty values are allowed.
+func (p *Parser) replaceValues(replace func(value string) string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	count := 0
+	for name, keys := range p.parsedData {
+		for key, value := range keys {
+			values, ok := p.values[name][key]
+			if !ok {
+				values = []string{value}
+			}
+			changed := false
+			for i, v := range values {
+				if r := replace(v); r != v && (r != "" || p.allowEmptyValues) {
+					values[i] = r
+					changed = true
+					count++
+				}
+			}
+			if changed {
+				keys[key] = values[len(values)-1]
+				p.dropRaw(name, key)
+				p.markDirty(name)
+			}
+		}
+	}
+	return count
+}
+
+// RemoveEmptySections deletes every section that holds no keys, such as one
+// whose last key was removed with DeleteKey, along with its comments.
+func (p *Parser) RemoveEmptySections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, keys := range p.parsedData {
+		if len(keys) == 0 {
+			p.markDirty(name)
+			delete(p.parsedData, name)
+			delete(p.comments, name)
+			delete(p.values, name)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package iniparser
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetInt returns the value of key in section parsed as an int. Base
+// prefixes are honored as in Go literals: "0xFF" is hexadecimal, "0b101"
+// binary, and "0o755" or "0755" octal, so a decimal value must not have a
+// leading zero. It returns ErrValueNotInteger if the value is not a valid
+// integer.
+func (p *Parser) GetInt(section, key string) (int, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 0, strconv.IntSize)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrValueNotInteger, value)
+	}
+	return int(n), nil
+}
+
+// GetInt64 is like GetInt but returns an int64, for values such as byte
+// counts that may not fit in an int on every platform.
+func (p *Parser) GetInt64(section, key string) (int64, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrValueNotInteger, value)
+	}
+	return n, nil
+}
+
+// GetUint returns the value of key in section parsed as a uint64, with the
+// same base prefixes as GetInt. It returns ErrValueNotInteger if the value
+// is not a valid integer or is negative.
+func (p *Parser) GetUint(section, key string) (uint64, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(value, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrValueNotInteger, value)
+	}
+	return n, nil
+}
+
+// GetBool returns the value of key in section parsed with strconv.ParseBool.
+// It returns ErrValueNotBool if the value is not a valid boolean.
+func (p *Parser) GetBool(section, key string) (bool, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %q", ErrValueNotBool, value)
+	}
+	return b, nil
+}
+
+// GetFloat returns the value of key in section parsed as a float64. It
+// returns ErrValueNotFloat if the value is not a valid number.
+func (p *Parser) GetFloat(section, key string) (float64, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrValueNotFloat, value)
+	}
+	return f, nil
+}
+
+// GetIntOrDefault returns the value of key in section as an int, or def if
+// the key is missing or its value is not a valid integer.
+func (p *Parser) GetIntOrDefault(section, key string, def int) int {
+	n, err := p.GetInt(section, key)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// GetBoolOrDefault returns the value of key in section as a bool, or def if
+// the key is missing or its value is not a valid boolean.
+func (p *Parser) GetBoolOrDefault(section, key string, def bool) bool {
+	b, err := p.GetBool(section, key)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// GetFloatOrDefault returns the value of key in section as a float64, or def
+// if the key is missing or its value is not a valid number.
+func (p *Parser) GetFloatOrDefault(section, key string, def float64) float64 {
+	f, err := p.GetFloat(section, key)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+// GetDuration returns the value of key in section parsed with
+// time.ParseDuration, e.g. "30s" or "5m". It returns ErrNotADuration if the
+// value is not a valid duration.
+func (p *Parser) GetDuration(section, key string) (time.Duration, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrNotADuration, value)
+	}
+	return d, nil
+}
+
+// GetIP returns the value of key in section parsed with net.ParseIP, in
+// IPv4 dotted decimal or IPv6 form. It returns ErrNotAnIP if the value is
+// not a valid IP address.
+func (p *Parser) GetIP(section, key string) (net.IP, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %q", ErrNotAnIP, value)
+	}
+	return ip, nil
+}
+
+// GetURL returns the value of key in section parsed with url.Parse. It
+// returns ErrNotAURL, wrapping the parse error, if the value is not a valid
+// URL.
+func (p *Parser) GetURL(section, key string) (*url.URL, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotAURL, err)
+	}
+	return u, nil
+}
+
+// byteUnits maps the lower-cased unit suffixes accepted by GetBytes to
+// their size in bytes.
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// GetBytes returns the value of key in section parsed as a size in bytes,
+// such as "10MB", "1 KiB" or a bare "512". Units are case-insensitive;
+// KB, MB, GB and TB are powers of 1000 and KiB, MiB, GiB and TiB powers of
+// 1024. It returns ErrNotAByteSize for a missing number, an unknown unit or
+// a size that overflows an int64.
+func (p *Parser) GetBytes(section, key string) (int64, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := parseByteSize(value)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrNotAByteSize, value)
+	}
+	return n, nil
+}
+
+// parseByteSize parses a non-negative integer followed by an optional unit
+// from byteUnits.
+func parseByteSize(s string) (int64, bool) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok || n > math.MaxInt64/unit {
+		return 0, false
+	}
+	return n * unit, true
+}
+
+// GetEnum returns the value of key in section if it is one of allowed,
+// such as "debug", "info", "warn" or "error" for a log level. It returns
+// ErrInvalidEnumValue, listing allowed, for any other value.
+func (p *Parser) GetEnum(section, key string, allowed []string) (string, error) {
+	return p.getEnum(section, key, allowed, false)
+}
+
+// GetEnumFold is like GetEnum but matches the value against allowed
+// ignoring case, and returns the matching element of allowed, so "INFO"
+// yields "info".
+func (p *Parser) GetEnumFold(section, key string, allowed []string) (string, error) {
+	return p.getEnum(section, key, allowed, true)
+}
+
+// getEnum implements GetEnum and, with fold set, GetEnumFold.
+func (p *Parser) getEnum(section, key string, allowed []string, fold bool) (string, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return "", err
+	}
+	for _, option := range allowed {
+		if option == value || fold && strings.EqualFold(option, value) {
+			return option, nil
+		}
+	}
+	quoted := make([]string, len(allowed))
+	for i, option := range allowed {
+		quoted[i] = strconv.Quote(option)
+	}
+	return "", fmt.Errorf("%w: %q, want one of %s", ErrInvalidEnumValue, value, strings.Join(quoted, ", "))
+}
+
+// GetStringSlice splits the value of key in section on sep and returns the
+// elements with surrounding whitespace trimmed. Empty elements, such as the
+// one produced by a trailing separator in "a,b,", are dropped. A value
+// without sep yields a one-element slice. An empty sep is rejected with
+// ErrSeparatorIsEmpty rather than splitting the value into characters.
+func (p *Parser) GetStringSlice(section, key, sep string) ([]string, error) {
+	if sep == "" {
+		return nil, ErrSeparatorIsEmpty
+	}
+	value, err := p.Get(section, key)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(value, sep)
+	elems := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			elems = append(elems, part)
+		}
+	}
+	return elems, nil
+}
@@ -0,0 +1,312 @@
+package iniparser
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const typedConfig = `[s]
+int = 42
+bool = true
+float = 2.5
+bad = oops
+`
+
+func parseTyped(t *testing.T) *Parser {
+	t.Helper()
+	p, err := Parse(typedConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestGetOrDefault(t *testing.T) {
+	p := parseTyped(t)
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"int present", p.GetIntOrDefault("s", "int", 7), 42},
+		{"int invalid", p.GetIntOrDefault("s", "bad", 7), 7},
+		{"int absent", p.GetIntOrDefault("s", "missing", 7), 7},
+		{"int no section", p.GetIntOrDefault("none", "int", 7), 7},
+		{"bool present", p.GetBoolOrDefault("s", "bool", false), true},
+		{"bool invalid", p.GetBoolOrDefault("s", "bad", true), true},
+		{"bool absent", p.GetBoolOrDefault("s", "missing", true), true},
+		{"float present", p.GetFloatOrDefault("s", "float", 1), 2.5},
+		{"float invalid", p.GetFloatOrDefault("s", "bad", 1), 1.0},
+		{"float absent", p.GetFloatOrDefault("s", "missing", 1), 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetIntBases(t *testing.T) {
+	p, err := Parse("[hw]\nmask=0xFF\nmode=0755\nmode2=0o755\nflags=0b101\ndec=42\nneg=-0x10\nbad=0x\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key     string
+		want    int
+		wantErr error
+	}{
+		{"mask", 255, nil},
+		{"mode", 0755, nil},
+		{"mode2", 0755, nil},
+		{"flags", 5, nil},
+		{"dec", 42, nil},
+		{"neg", -16, nil},
+		{"bad", 0, ErrValueNotInteger},
+		{"missing", 0, ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := p.GetInt("hw", tt.key)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("GetInt(%q) = %d, %v, want %d, %v", tt.key, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetInt64AndUint(t *testing.T) {
+	p, err := Parse("[s]\nbig=5000000000\nneg=-1\nmax=18446744073709551615\nbad=ten\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	int64Tests := []struct {
+		key     string
+		want    int64
+		wantErr error
+	}{
+		{"big", 5000000000, nil},
+		{"neg", -1, nil},
+		{"max", 0, ErrValueNotInteger},
+		{"bad", 0, ErrValueNotInteger},
+	}
+	for _, tt := range int64Tests {
+		if got, err := p.GetInt64("s", tt.key); !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("GetInt64(%q) = %d, %v, want %d, %v", tt.key, got, err, tt.want, tt.wantErr)
+		}
+	}
+	uintTests := []struct {
+		key     string
+		want    uint64
+		wantErr error
+	}{
+		{"big", 5000000000, nil},
+		{"max", 18446744073709551615, nil},
+		{"neg", 0, ErrValueNotInteger},
+		{"bad", 0, ErrValueNotInteger},
+		{"missing", 0, ErrKeyNotFound},
+	}
+	for _, tt := range uintTests {
+		if got, err := p.GetUint("s", tt.key); !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("GetUint(%q) = %d, %v, want %d, %v", tt.key, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGetBytes(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr error
+	}{
+		{"10MB", 10_000_000, nil},
+		{"1KiB", 1024, nil},
+		{"1 kib", 1024, nil},
+		{"512", 512, nil},
+		{"2GiB", 2 << 30, nil},
+		{"3B", 3, nil},
+		{"10XB", 0, ErrNotAByteSize},
+		{"MB", 0, ErrNotAByteSize},
+		{"1.5MB", 0, ErrNotAByteSize},
+		{"9999999TiB", 0, ErrNotAByteSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			p, err := Parse("[s]\nsize=" + tt.value + "\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.GetBytes("s", "size")
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("GetBytes() = %d, %v, want %d, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetIP(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    net.IP
+		wantErr error
+	}{
+		{"192.0.2.62", net.ParseIP("192.0.2.62"), nil},
+		{"2001:db8::1", net.ParseIP("2001:db8::1"), nil},
+		{"::ffff:192.0.2.1", net.ParseIP("192.0.2.1"), nil},
+		{"192.0.2.256", nil, ErrNotAnIP},
+		{"example.com", nil, ErrNotAnIP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			p, err := Parse("[s]\nserver=" + tt.value + "\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.GetIP("s", "server")
+			if !errors.Is(err, tt.wantErr) || !got.Equal(tt.want) {
+				t.Errorf("GetIP() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetURL(t *testing.T) {
+	tests := []struct {
+		value    string
+		wantHost string
+		wantErr  error
+	}{
+		{"https://example.com:8443/path?q=1", "example.com:8443", nil},
+		{"postgres://user:pass@db/app", "db", nil},
+		{"/relative/path", "", nil},
+		{"http://[::1", "", ErrNotAURL},
+		{"://missing-scheme", "", ErrNotAURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			p, err := Parse("[s]\nurl=" + tt.value + "\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.GetURL("s", "url")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Host != tt.wantHost {
+				t.Errorf("GetURL().Host = %q, want %q", got.Host, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestTypedGettersMissing(t *testing.T) {
+	p := parseTyped(t)
+	if _, err := p.GetIP("s", "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("GetIP() error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if _, err := p.GetURL("none", "url"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("GetURL() error = %v, want %v", err, ErrSectionNotFound)
+	}
+}
+
+func TestGetEnum(t *testing.T) {
+	levels := []string{"debug", "info", "warn", "error"}
+	tests := []struct {
+		name    string
+		value   string
+		fold    bool
+		want    string
+		wantErr error
+	}{
+		{"valid", "warn", false, "warn", nil},
+		{"invalid", "verbose", false, "", ErrInvalidEnumValue},
+		{"case mismatch", "INFO", false, "", ErrInvalidEnumValue},
+		{"case mismatch folded", "INFO", true, "info", nil},
+		{"invalid folded", "verbose", true, "", ErrInvalidEnumValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("[log]\nlevel=" + tt.value + "\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			get := p.GetEnum
+			if tt.fold {
+				get = p.GetEnumFold
+			}
+			got, err := get("log", "level", levels)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Fatalf("got %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), `"debug", "info", "warn", "error"`) {
+				t.Errorf("error %q does not list the allowed values", err)
+			}
+		})
+	}
+}
+
+func TestGetDuration(t *testing.T) {
+	p, err := Parse("[s]\ntimeout=30s\ninterval=1h5m\nbad=30\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		section string
+		key     string
+		want    time.Duration
+		wantErr error
+	}{
+		{"s", "timeout", 30 * time.Second, nil},
+		{"s", "interval", time.Hour + 5*time.Minute, nil},
+		{"s", "bad", 0, ErrNotADuration},
+		{"s", "missing", 0, ErrKeyNotFound},
+		{"none", "timeout", 0, ErrSectionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.section+"."+tt.key, func(t *testing.T) {
+			got, err := p.GetDuration(tt.section, tt.key)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("GetDuration(%q, %q) = %v, %v, want %v, %v", tt.section, tt.key, got, err, tt.want, tt.wantErr)
+			}
+			if errors.Is(err, ErrNotADuration) && !strings.Contains(err.Error(), `"30"`) {
+				t.Errorf("error %q does not include the raw value", err)
+			}
+		})
+	}
+}
+
+func TestGetStringSlice(t *testing.T) {
+	p, err := Parse("[s]\nhosts=a.com,b.com,c.com\nspaced= a , b ,c \ntrailing=a,b,\nsingle=only\nempties=,, ,\npipes=x|y\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key     string
+		sep     string
+		want    []string
+		wantErr error
+	}{
+		{"hosts", ",", []string{"a.com", "b.com", "c.com"}, nil},
+		{"spaced", ",", []string{"a", "b", "c"}, nil},
+		{"trailing", ",", []string{"a", "b"}, nil},
+		{"single", ",", []string{"only"}, nil},
+		{"empties", ",", []string{}, nil},
+		{"pipes", "|", []string{"x", "y"}, nil},
+		{"hosts", "", nil, ErrSeparatorIsEmpty},
+		{"missing", ",", nil, ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.sep, func(t *testing.T) {
+			got, err := p.GetStringSlice("s", tt.key, tt.sep)
+			if !errors.Is(err, tt.wantErr) || !slices.Equal(got, tt.want) {
+				t.Errorf("GetStringSlice(%q, %q) = %q, %v, want %q, %v", tt.key, tt.sep, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package iniparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Validate checks that every section in required exists and holds the keys
+// listed for it. Keys are looked up as by Get, so one inherited from the
+// default section counts as present. Rather than stopping at the first gap
+// it reports all of them in one error wrapping ErrMissingRequired, or
+// returns nil if nothing is missing.
+func (p *Parser) Validate(required map[string][]string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var missing []string
+	for _, name := range sortedKeys(required) {
+		if _, ok := p.section(name); !ok {
+			missing = append(missing, "section "+strconv.Quote(name))
+			continue
+		}
+		for _, key := range required[name] {
+			if _, _, err := p.lookup(name, key); err != nil {
+				missing = append(missing, fmt.Sprintf("key %q in section %q", key, name))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingRequired, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// GetRequired is like Get for a setting the caller cannot do without. If the
+// section or key is missing, the error names both and wraps
+// ErrMissingRequired as well as ErrSectionNotFound or ErrKeyNotFound.
+func (p *Parser) GetRequired(section, key string) (string, error) {
+	value, err := p.Get(section, key)
+	if err != nil {
+		return "", fmt.Errorf("%w: key %q in section %q (%w)", ErrMissingRequired, key, section, err)
+	}
+	return value, nil
+}
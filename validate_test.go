@@ -0,0 +1,106 @@
+package iniparser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	p, err := Parse("[server]\nhost=localhost\n\n[log]\nlevel=info\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		required map[string][]string
+		want     []string
+	}{
+		{"all present", map[string][]string{"server": {"host"}, "log": {"level"}}, nil},
+		{"missing key", map[string][]string{"server": {"host", "port"}}, []string{`key "port" in section "server"`}},
+		{
+			"missing section and key",
+			map[string][]string{"server": {"port"}, "database": {"url"}},
+			[]string{`section "database"`, `key "port" in section "server"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Validate(tt.required)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMissingRequired) {
+				t.Fatalf("Validate() error = %v, want %v", err, ErrMissingRequired)
+			}
+			for _, missing := range tt.want {
+				if !strings.Contains(err.Error(), missing) {
+					t.Errorf("Validate() error = %q, want it to mention %s", err, missing)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRequired(t *testing.T) {
+	p, err := Parse("[server]\nhost=localhost\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		section string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"present", "server", "host", "localhost", nil},
+		{"missing key", "server", "port", "", ErrKeyNotFound},
+		{"missing section", "database", "url", "", ErrSectionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.GetRequired(tt.section, tt.key)
+			if got != tt.want {
+				t.Errorf("GetRequired() = %q, want %q", got, tt.want)
+			}
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("GetRequired() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMissingRequired) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetRequired() error = %v, want %v and %v", err, ErrMissingRequired, tt.wantErr)
+			}
+			for _, name := range []string{tt.section, tt.key} {
+				if !strings.Contains(err.Error(), strconv.Quote(name)) {
+					t.Errorf("GetRequired() error = %q, want it to name %q", err, name)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDefaultSection(t *testing.T) {
+	p, err := Parse("[DEFAULT]\ntimeout=30\n\n[server]\nhost=localhost\n", WithDefaultSection("DEFAULT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	required := map[string][]string{"server": {"host", "timeout"}}
+	if _, err := p.Get("server", "timeout"); err != nil {
+		t.Fatalf("Get(server, timeout) error = %v", err)
+	}
+	if err := p.Validate(required); err != nil {
+		t.Errorf("Validate() error = %v, want the inherited key to count", err)
+	}
+
+	p.SetDefaultSection("")
+	if err := p.Validate(required); !errors.Is(err, ErrMissingRequired) {
+		t.Errorf("Validate() without a default section error = %v, want %v", err, ErrMissingRequired)
+	}
+}
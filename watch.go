@@ -0,0 +1,69 @@
+package iniparser
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// watchInterval is how often WatchFile checks the file for changes.
+var watchInterval = time.Second
+
+// WatchFile polls the file at path in the background and reloads the
+// parser whenever its modification time or size changes, until ctx is
+// cancelled. onReload, if not nil, is called after every reload attempt
+// with its error; when a reload fails the previously loaded content is
+// kept. Reloads swap the content under the parser's lock, so concurrent
+// reads stay safe. An empty file is not loaded: it is most likely caught
+// between an editor's truncate and write, so the watcher waits for the
+// content to arrive instead. WatchFile returns at once, with an error only if path
+// cannot be accessed to begin with.
+func (p *Parser) WatchFile(ctx context.Context, path string, onReload func(error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		modTime, size := info.ModTime(), info.Size()
+		statFailed := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				// Report a missing or unreadable file once, not on every tick.
+				if !statFailed && onReload != nil {
+					onReload(err)
+				}
+				statFailed = true
+				continue
+			}
+			statFailed = false
+			if info.ModTime().Equal(modTime) && info.Size() == size {
+				continue
+			}
+			if info.Size() == 0 {
+				// Not recorded, so the write that follows is still seen as
+				// a change.
+				continue
+			}
+			// Recorded before loading, so a write that lands during the
+			// load is picked up on the next tick.
+			modTime, size = info.ModTime(), info.Size()
+
+			err = p.LoadFromFile(path)
+			if onReload != nil {
+				onReload(err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,98 @@
+package iniparser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+	defer func(d time.Duration) { watchInterval = d }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	path := writeFiles(t, "[s]\nk=old\n")[0]
+	p := NewParser()
+	if err := p.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloads := make(chan error, 10)
+	if err := p.WatchFile(ctx, path, func(err error) { reloads <- err }); err != nil {
+		t.Fatalf("WatchFile() error = %v", err)
+	}
+
+	wait := func() error {
+		t.Helper()
+		select {
+		case err := <-reloads:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reload after the file changed")
+			return nil
+		}
+	}
+
+	// Each write changes the size, so it is noticed even when the
+	// modification time has a coarse resolution.
+	if err := os.WriteFile(path, []byte("[s]\nk=newer\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(); err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if got, _ := p.Get("s", "k"); got != "newer" {
+		t.Errorf("Get(s, k) after reload = %q, want newer", got)
+	}
+
+	if err := os.WriteFile(path, []byte("[broken\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(); !errors.Is(err, ErrMalformedSectionHeader) {
+		t.Fatalf("reload error = %v, want %v", err, ErrMalformedSectionHeader)
+	}
+	if got, _ := p.Get("s", "k"); got != "newer" {
+		t.Errorf("Get(s, k) after a failed reload = %q, want the previous value", got)
+	}
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloads:
+		t.Fatalf("reloaded an empty file with error %v", err)
+	case <-time.After(10 * watchInterval):
+	}
+	if got, _ := p.Get("s", "k"); got != "newer" {
+		t.Errorf("Get(s, k) after truncating = %q, want the previous value", got)
+	}
+	if err := os.WriteFile(path, []byte("[s]\nk=rewritten\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(); err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if got, _ := p.Get("s", "k"); got != "rewritten" {
+		t.Errorf("Get(s, k) after the write = %q, want rewritten", got)
+	}
+
+	cancel()
+	time.Sleep(5 * watchInterval)
+	if err := os.WriteFile(path, []byte("[s]\nk=after cancel\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloads:
+		t.Errorf("reloaded after cancel with error %v", err)
+	case <-time.After(10 * watchInterval):
+	}
+}
+
+func TestWatchFileMissing(t *testing.T) {
+	err := NewParser().WatchFile(context.Background(), "does-not-exist.ini", nil)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("WatchFile() error = %v, want %v", err, os.ErrNotExist)
+	}
+}